@@ -0,0 +1,240 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logfmt/logfmt"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogFormat decides how LogMiddleware renders a request.
+type LogFormat int
+
+const (
+	// LogFormatPlog logs through the plog logger, it is the default format.
+	LogFormatPlog LogFormat = iota
+	// LogFormatCommon is the Apache Common Log Format.
+	LogFormatCommon
+	// LogFormatCombined is the Apache Combined Log Format.
+	LogFormatCombined
+	// LogFormatJSON writes one json object per line.
+	LogFormatJSON
+	// LogFormatLogfmt writes one logfmt record per line.
+	LogFormatLogfmt
+)
+
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// WithLogFormat selects the access log format. All formats other than LogFormatPlog
+// are written to the access log output instead of the plog logger, which is os.Stdout
+// unless WithLogOutput or WithLogRotate is used.
+func WithLogFormat(format LogFormat) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.format = format
+	}
+}
+
+// WithLogOutput sets the writer of the access log.
+func WithLogOutput(w io.Writer) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.output = &syncWriter{w: w}
+	}
+}
+
+// WithLogRotate writes the access log into a file rotated by lumberjack.
+// conf is copied, the logger passed in is left untouched.
+func WithLogRotate(conf *lumberjack.Logger) LogOption {
+	return func(lm *LogMiddleware) {
+		rotate := &lumberjack.Logger{
+			Filename:   conf.Filename,
+			MaxSize:    conf.MaxSize,
+			MaxAge:     conf.MaxAge,
+			MaxBackups: conf.MaxBackups,
+			LocalTime:  conf.LocalTime,
+			Compress:   conf.Compress,
+		}
+		if rotate.Filename == "" {
+			rotate.Filename = "access.log"
+		}
+		lm.output = &syncWriter{w: rotate}
+	}
+}
+
+// syncWriter serializes the writes so that records of concurrent requests do not interleave.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+func newStdoutWriter() *syncWriter {
+	return &syncWriter{w: os.Stdout}
+}
+
+// accessRecord contains everything needed to render an access log line.
+type accessRecord struct {
+	time       time.Time
+	level      string
+	remoteAddr string
+	user       string
+	method     string
+	uri        string
+	proto      string
+	status     int
+	size       int64
+	duration   time.Duration
+	referer    string
+	userAgent  string
+	// fields are the optional key value pairs, see LogField
+	fields []any
+	err    error
+	errMsg string
+}
+
+func newAccessRecord(ctx *Context, status int, duration time.Duration, level string) *accessRecord {
+	r := ctx.Request
+	user, _, _ := r.BasicAuth()
+	if user == "" && r.URL.User != nil {
+		user = r.URL.User.Username()
+	}
+
+	return &accessRecord{
+		time:       ctx.startTime,
+		level:      level,
+		remoteAddr: ctx.ClientIp,
+		user:       user,
+		method:     ctx.Method,
+		uri:        r.RequestURI,
+		proto:      r.Proto,
+		status:     status,
+		size:       ctx.Writer.Size(),
+		duration:   duration,
+		referer:    r.Referer(),
+		userAgent:  r.UserAgent(),
+	}
+}
+
+func clfValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+func (a *accessRecord) writeCommon(buf *bytes.Buffer) {
+	size := "-"
+	if a.size > 0 {
+		size = strconv.FormatInt(a.size, 10)
+	}
+
+	uri := a.uri
+	if uri == "" {
+		uri = "/"
+	}
+
+	fmt.Fprintf(buf, "%s - %s [%s] %s %d %s",
+		clfValue(a.remoteAddr),
+		clfValue(a.user),
+		a.time.Format(clfTimeLayout),
+		strconv.Quote(a.method+" "+uri+" "+a.proto),
+		a.status,
+		size,
+	)
+}
+
+func (a *accessRecord) writeCombined(buf *bytes.Buffer) {
+	a.writeCommon(buf)
+	fmt.Fprintf(buf, " %s %s", strconv.Quote(clfValue(a.referer)), strconv.Quote(clfValue(a.userAgent)))
+}
+
+// keyvals returns the record as alternating keys and values.
+func (a *accessRecord) keyvals() []any {
+	kvs := []any{
+		"time", a.time.Format(time.RFC3339Nano),
+		"level", a.level,
+		"clientIp", a.remoteAddr,
+		"method", a.method,
+		"path", a.uri,
+		"proto", a.proto,
+		"statusCode", a.status,
+		"duration", a.duration.String(),
+	}
+	kvs = append(kvs, a.fields...)
+
+	if a.err != nil {
+		kvs = append(kvs, "err", a.err.Error())
+		if a.errMsg != "" {
+			kvs = append(kvs, "errMsg", a.errMsg)
+		}
+	}
+
+	return kvs
+}
+
+func (a *accessRecord) writeJSON(buf *bytes.Buffer) {
+	kvs := a.keyvals()
+
+	buf.WriteByte('{')
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(fmt.Sprint(kvs[i]))
+		val, err := json.Marshal(kvs[i+1])
+		if err != nil {
+			val, _ = json.Marshal(fmt.Sprint(kvs[i+1]))
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+}
+
+func (a *accessRecord) writeLogfmt(buf *bytes.Buffer) {
+	_ = logfmt.NewEncoder(buf).EncodeKeyvals(a.keyvals()...)
+}
+
+func (a *accessRecord) render(format LogFormat) []byte {
+	buf := new(bytes.Buffer)
+
+	switch format {
+	case LogFormatCommon:
+		a.writeCommon(buf)
+	case LogFormatCombined:
+		a.writeCombined(buf)
+	case LogFormatJSON:
+		a.writeJSON(buf)
+	case LogFormatLogfmt:
+		a.writeLogfmt(buf)
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes()
+}
+
+func levelName(statusCode int) string {
+	switch {
+	case statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices:
+		return "info"
+	case statusCode >= http.StatusMultipleChoices && statusCode < http.StatusBadRequest:
+		return "warn"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,147 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestAccessRecordRender(t *testing.T) {
+	record := func() *accessRecord {
+		return &accessRecord{
+			time:       time.Date(2024, time.March, 5, 14, 7, 9, 120000000, time.FixedZone("", 2*60*60)),
+			level:      "info",
+			remoteAddr: "10.0.0.1",
+			user:       "alice",
+			method:     http.MethodGet,
+			uri:        "/users/7?full=1",
+			proto:      "HTTP/1.1",
+			status:     http.StatusOK,
+			size:       512,
+			duration:   1500 * time.Microsecond,
+			referer:    "https://example.com/",
+			userAgent:  `curl/8.0 "x"`,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		format LogFormat
+		edit   func(a *accessRecord)
+		want   string
+	}{
+		{
+			name:   "common",
+			format: LogFormatCommon,
+			want:   `10.0.0.1 - alice [05/Mar/2024:14:07:09 +0200] "GET /users/7?full=1 HTTP/1.1" 200 512`,
+		},
+		{
+			name:   "common without user and body",
+			format: LogFormatCommon,
+			edit: func(a *accessRecord) {
+				a.user, a.size, a.status, a.uri = "", 0, http.StatusNoContent, ""
+			},
+			want: `10.0.0.1 - - [05/Mar/2024:14:07:09 +0200] "GET / HTTP/1.1" 204 -`,
+		},
+		{
+			name:   "combined",
+			format: LogFormatCombined,
+			want:   `10.0.0.1 - alice [05/Mar/2024:14:07:09 +0200] "GET /users/7?full=1 HTTP/1.1" 200 512 "https://example.com/" "curl/8.0 \"x\""`,
+		},
+		{
+			name:   "combined without referer",
+			format: LogFormatCombined,
+			edit:   func(a *accessRecord) { a.referer, a.userAgent = "", "" },
+			want:   `10.0.0.1 - alice [05/Mar/2024:14:07:09 +0200] "GET /users/7?full=1 HTTP/1.1" 200 512 "-" "-"`,
+		},
+		{
+			name:   "json",
+			format: LogFormatJSON,
+			edit:   func(a *accessRecord) { a.fields = []any{"route", "/users/{id}", "responseSize", int64(512)} },
+			want: `{"time":"2024-03-05T14:07:09.12+02:00","level":"info","clientIp":"10.0.0.1","method":"GET",` +
+				`"path":"/users/7?full=1","proto":"HTTP/1.1","statusCode":200,"duration":"1.5ms",` +
+				`"route":"/users/{id}","responseSize":512}`,
+		},
+		{
+			name:   "json with error",
+			format: LogFormatJSON,
+			edit: func(a *accessRecord) {
+				a.status, a.level, a.err, a.errMsg = http.StatusInternalServerError, "error", errors.New("db down"), "try later"
+			},
+			want: `{"time":"2024-03-05T14:07:09.12+02:00","level":"error","clientIp":"10.0.0.1","method":"GET",` +
+				`"path":"/users/7?full=1","proto":"HTTP/1.1","statusCode":500,"duration":"1.5ms",` +
+				`"err":"db down","errMsg":"try later"}`,
+		},
+		{
+			name:   "logfmt",
+			format: LogFormatLogfmt,
+			edit:   func(a *accessRecord) { a.fields = []any{"userAgent", a.userAgent} },
+			want: `time=2024-03-05T14:07:09.12+02:00 level=info clientIp=10.0.0.1 method=GET path="/users/7?full=1" ` +
+				`proto=HTTP/1.1 statusCode=200 duration=1.5ms userAgent="curl/8.0 \"x\""`,
+		},
+		{
+			name:   "logfmt with error",
+			format: LogFormatLogfmt,
+			edit: func(a *accessRecord) {
+				a.status, a.level, a.err = http.StatusBadGateway, "error", errors.New("upstream closed")
+			},
+			want: `time=2024-03-05T14:07:09.12+02:00 level=error clientIp=10.0.0.1 method=GET path="/users/7?full=1" ` +
+				`proto=HTTP/1.1 statusCode=502 duration=1.5ms err="upstream closed"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := record()
+			if tt.edit != nil {
+				tt.edit(a)
+			}
+			if got := string(a.render(tt.format)); got != tt.want+"\n" {
+				t.Errorf("render() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogOutput(t *testing.T) {
+	out := new(strings.Builder)
+	r := New()
+	r.UseMiddleware(NewLogMiddleware(WithLogFormat(LogFormatCommon), WithLogOutput(out)))
+	r.GET("/users/{id}", textHandler("user"))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7?full=1", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.SetBasicAuth("alice", "secret")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := out.String()
+	prefix := "10.0.0.1 - alice ["
+	suffix := `] "GET /users/7?full=1 HTTP/1.1" 200 4` + "\n"
+	if !strings.HasPrefix(line, prefix) || !strings.HasSuffix(line, suffix) {
+		t.Fatalf("line = %q, want %q<time>%q", line, prefix, suffix)
+	}
+	if _, err := time.Parse(clfTimeLayout, strings.TrimSuffix(strings.TrimPrefix(line, prefix), suffix)); err != nil {
+		t.Errorf("time: %v", err)
+	}
+}
+
+func TestWithLogRotateCopiesConfig(t *testing.T) {
+	conf := &lumberjack.Logger{MaxSize: 5}
+	lm := NewLogMiddleware(WithLogRotate(conf))
+
+	if conf.Filename != "" {
+		t.Errorf("the config of the caller was changed: Filename = %q", conf.Filename)
+	}
+	rotate, ok := lm.output.(*syncWriter).w.(*lumberjack.Logger)
+	if !ok || rotate == conf {
+		t.Fatalf("output = %T, want a copy of the config", lm.output.(*syncWriter).w)
+	}
+	if rotate.Filename != "access.log" || rotate.MaxSize != 5 {
+		t.Errorf("Filename = %q, MaxSize = %d", rotate.Filename, rotate.MaxSize)
+	}
+}
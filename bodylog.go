@@ -0,0 +1,349 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const (
+	defaultBodyLogLimit = 4 << 10
+	redactedValue       = "***"
+)
+
+var defaultBodyContentTypes = []string{"application/json", "application/x-www-form-urlencoded", "text/*"}
+
+// capturedBody is the request and response bodies captured by BodyLogMiddleware,
+// the bodies have been redacted already.
+type capturedBody struct {
+	request  string
+	response string
+	headers  http.Header
+}
+
+func (c *capturedBody) args() []any {
+	if c == nil {
+		return nil
+	}
+
+	args := []any{"requestHeaders", c.headers, "requestBody", c.request}
+	if c.response != "" {
+		args = append(args, "responseBody", c.response)
+	}
+	return args
+}
+
+// BodyLogMiddleware logs the request and response bodies for debugging.
+// Only the bodies whose content type is configured are captured, sensitive json
+// fields and headers are redacted before logging.
+type BodyLogMiddleware struct {
+	logger       plog.Logger
+	limit        int
+	contentTypes []string
+	redactor     *redactor
+}
+
+type BodyLogOption func(*BodyLogMiddleware)
+
+func WithBodyLogger(l plog.Logger) BodyLogOption {
+	return func(m *BodyLogMiddleware) {
+		m.logger = l
+	}
+}
+
+// WithBodyLimit sets the max number of bytes captured of each body.
+func WithBodyLimit(limit int) BodyLogOption {
+	return func(m *BodyLogMiddleware) {
+		m.limit = limit
+	}
+}
+
+// WithBodyContentTypes replaces the captured content types, a type can end with /* to match all subtypes.
+func WithBodyContentTypes(types ...string) BodyLogOption {
+	return func(m *BodyLogMiddleware) {
+		m.contentTypes = types
+	}
+}
+
+// WithBodyRedactPaths redacts json fields by path such as $.password, $.card.number or $.items[*].token.
+// Top level paths also apply to form fields.
+func WithBodyRedactPaths(paths ...string) BodyLogOption {
+	return func(m *BodyLogMiddleware) {
+		for _, p := range paths {
+			m.redactor.paths = append(m.redactor.paths, parseRedactPath(p))
+		}
+	}
+}
+
+// WithBodyRedactHeaders adds headers whose value is masked,
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie are masked by default.
+func WithBodyRedactHeaders(headers ...string) BodyLogOption {
+	return func(m *BodyLogMiddleware) {
+		m.redactor.addHeaders(headers...)
+	}
+}
+
+func NewBodyLogMiddleware(opts ...BodyLogOption) *BodyLogMiddleware {
+	m := &BodyLogMiddleware{
+		logger:       plog.GetLogger(),
+		limit:        defaultBodyLogLimit,
+		contentTypes: defaultBodyContentTypes,
+		redactor:     newRedactor(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *BodyLogMiddleware) captured(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, ct := range m.contentTypes {
+		if prefix, ok := strings.CutSuffix(ct, "/*"); ok {
+			if strings.HasPrefix(mt, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if mt == ct {
+			return true
+		}
+	}
+	return false
+}
+
+// captureRequest reads up to limit bytes of the request body and puts them back in front of the rest.
+func (m *BodyLogMiddleware) captureRequest(r *http.Request) string {
+	if r.Body == nil || r.Body == http.NoBody || !m.captured(r.Header.Get("Content-Type")) {
+		return ""
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(r.Body, int64(m.limit)+1))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+	if err != nil {
+		return fmt.Sprintf("[read body error: %v]", err)
+	}
+
+	return m.redactor.body(r.Header.Get("Content-Type"), prefix, m.limit)
+}
+
+func (m *BodyLogMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		capture := &capturedBody{
+			headers: m.redactor.header(ctx.Request.Header),
+			request: m.captureRequest(ctx.Request),
+		}
+		ctx.bodyCapture = capture
+
+		cw := &captureWriter{passthroughWriter: passthroughWriter{ctx.Writer.ResponseWriter}, limit: m.limit}
+		ctx.Writer.ResponseWriter = cw
+
+		ctx.onFinish(func() {
+			ctx.Writer.ResponseWriter = cw.ResponseWriter
+			if m.captured(ctx.Writer.Header().Get("Content-Type")) {
+				capture.response = m.redactor.body(ctx.Writer.Header().Get("Content-Type"), cw.buf.Bytes(), m.limit)
+			}
+
+			args := append([]any{ctx.Path}, capture.args()...)
+			m.logger.Infoc(ctx, "http body: %v.", args...)
+		})
+
+		return handler.Handle(ctx)
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter keeps the first limit bytes written to the response.
+type captureWriter struct {
+	passthroughWriter
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if remain := w.limit + 1 - w.buf.Len(); remain > 0 {
+		w.buf.Write(p[:min(len(p), remain)])
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// redactPath is a parsed json path, an int segment is an array index and -1 matches every element.
+type redactPath []any
+
+func parseRedactPath(p string) redactPath {
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+
+	var segs redactPath
+	for _, part := range strings.Split(p, ".") {
+		for part != "" {
+			open := strings.IndexByte(part, '[')
+			if open < 0 {
+				segs = append(segs, part)
+				break
+			}
+			if open > 0 {
+				segs = append(segs, part[:open])
+			}
+
+			end := strings.IndexByte(part[open:], ']')
+			if end < 0 {
+				segs = append(segs, part[open:])
+				break
+			}
+
+			idx := part[open+1 : open+end]
+			if n, err := strconv.Atoi(idx); err == nil {
+				segs = append(segs, n)
+			} else if idx == "*" {
+				segs = append(segs, -1)
+			} else {
+				segs = append(segs, strings.Trim(idx, `'"`))
+			}
+			part = part[open+end+1:]
+		}
+	}
+	return segs
+}
+
+type redactor struct {
+	paths   []redactPath
+	headers map[string]struct{}
+}
+
+func newRedactor() *redactor {
+	r := &redactor{headers: make(map[string]struct{})}
+	r.addHeaders(sensitiveHeaders...)
+	return r
+}
+
+func (r *redactor) addHeaders(headers ...string) {
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+}
+
+// header returns a copy of h with the sensitive values masked.
+func (r *redactor) header(h http.Header) http.Header {
+	ret := h.Clone()
+	for key := range ret {
+		if _, ok := r.headers[http.CanonicalHeaderKey(key)]; ok {
+			ret[key] = []string{redactedValue}
+		}
+	}
+	return ret
+}
+
+func (r *redactor) body(contentType string, data []byte, limit int) string {
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
+
+	mt, _, _ := mime.ParseMediaType(contentType)
+	ret := string(data)
+	if len(r.paths) > 0 {
+		switch {
+		case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+			// a truncated or malformed document can not be redacted safely
+			if truncated {
+				return fmt.Sprintf("[%d bytes truncated json body redacted]", len(data))
+			}
+			redacted, err := r.json(data)
+			if err != nil {
+				return fmt.Sprintf("[%d bytes malformed json body redacted]", len(data))
+			}
+			ret = redacted
+		case mt == "application/x-www-form-urlencoded":
+			redacted, err := r.form(ret)
+			if err != nil {
+				return fmt.Sprintf("[%d bytes malformed form body redacted]", len(data))
+			}
+			ret = redacted
+		}
+	}
+
+	if truncated {
+		ret += "...(truncated)"
+	}
+	return ret
+}
+
+func (r *redactor) json(data []byte) (string, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+
+	for _, p := range r.paths {
+		doc = redactValue(doc, p)
+	}
+
+	ret, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(ret), nil
+}
+
+func redactValue(v any, p redactPath) any {
+	if len(p) == 0 {
+		return redactedValue
+	}
+
+	switch seg := p[0].(type) {
+	case string:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		if child, exists := obj[seg]; exists {
+			obj[seg] = redactValue(child, p[1:])
+		}
+	case int:
+		arr, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i := range arr {
+			if seg == -1 || seg == i {
+				arr[i] = redactValue(arr[i], p[1:])
+			}
+		}
+	}
+	return v
+}
+
+func (r *redactor) form(body string) (string, error) {
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return "", err
+	}
+
+	for _, p := range r.paths {
+		if len(p) != 1 {
+			continue
+		}
+		if key, ok := p[0].(string); ok && values.Has(key) {
+			values.Set(key, redactedValue)
+		}
+	}
+	return values.Encode(), nil
+}
@@ -0,0 +1,135 @@
+package prouter
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestRedactorBody(t *testing.T) {
+	r := newRedactor()
+	for _, p := range []string{"$.password", "$.card.number", "$.items[*].token", "$.list[1]"} {
+		r.paths = append(r.paths, parseRedactPath(p))
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		limit       int
+		want        string
+	}{
+		{
+			name:        "top level field",
+			contentType: "application/json",
+			body:        `{"password":"secret","user":"bob"}`,
+			want:        `{"password":"***","user":"bob"}`,
+		},
+		{
+			name:        "nested field",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"card":{"number":"4111","exp":"12/30"}}`,
+			want:        `{"card":{"exp":"12/30","number":"***"}}`,
+		},
+		{
+			name:        "wildcard index",
+			contentType: "application/json",
+			body:        `{"items":[{"token":"a"},{"token":"b","id":1}]}`,
+			want:        `{"items":[{"token":"***"},{"id":1,"token":"***"}]}`,
+		},
+		{
+			name:        "fixed index",
+			contentType: "application/problem+json",
+			body:        `{"list":["a","b","c"]}`,
+			want:        `{"list":["a","***","c"]}`,
+		},
+		{
+			name:        "malformed json",
+			contentType: "application/json",
+			body:        `{"password":`,
+			want:        "[12 bytes malformed json body redacted]",
+		},
+		{
+			name:        "truncated json",
+			contentType: "application/json",
+			body:        `{"password":"secret"}`,
+			limit:       8,
+			want:        "[8 bytes truncated json body redacted]",
+		},
+		{
+			name:        "form field",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "password=secret&user=bob",
+			want:        "password=%2A%2A%2A&user=bob",
+		},
+		{
+			name:        "malformed form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "password=hunter2&x=%zz",
+			want:        "[22 bytes malformed form body redacted]",
+		},
+		{
+			name:        "truncated text",
+			contentType: "text/plain",
+			body:        "hello world",
+			limit:       5,
+			want:        "hello...(truncated)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			if limit == 0 {
+				limit = defaultBodyLogLimit
+			}
+			if got := r.body(tt.contentType, []byte(tt.body), limit); got != tt.want {
+				t.Errorf("body() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorHeader(t *testing.T) {
+	r := newRedactor()
+	r.addHeaders("x-api-key")
+
+	h := http.Header{
+		"Authorization": {"Bearer token"},
+		"Cookie":        {"a=b"},
+		"X-Api-Key":     {"key"},
+		"Accept":        {"*/*"},
+	}
+	got := r.header(h)
+
+	for _, key := range []string{"Authorization", "Cookie", "X-Api-Key"} {
+		if v := got.Get(key); v != redactedValue {
+			t.Errorf("%s = %q, want it masked", key, v)
+		}
+	}
+	if v := got.Get("Accept"); v != "*/*" {
+		t.Errorf("Accept = %q, want it kept", v)
+	}
+	if h.Get("Authorization") != "Bearer token" {
+		t.Error("header() modified the request header")
+	}
+}
+
+// The access log and the body log leave out the same headers.
+func TestSensitiveHeadersShared(t *testing.T) {
+	lm := NewLogMiddleware()
+	r := newRedactor()
+	for _, h := range sensitiveHeaders {
+		if _, ok := lm.headerDenylist[h]; !ok {
+			t.Errorf("%s is logged by LogMiddleware", h)
+		}
+		if _, ok := r.headers[h]; !ok {
+			t.Errorf("%s is not masked by BodyLogMiddleware", h)
+		}
+	}
+
+	args := lm.headerArgs(http.Header{"Authorization": {"x"}, "Accept": {"*/*"}})
+	if slices.Contains(args, any("header.Authorization")) || !slices.Contains(args, any("header.Accept")) {
+		t.Errorf("headerArgs() = %v", args)
+	}
+}
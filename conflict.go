@@ -0,0 +1,211 @@
+package prouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/gorilla/mux"
+)
+
+type ConflictKind string
+
+const (
+	// ConflictDuplicate is a route registered twice with the same method and path template.
+	ConflictDuplicate ConflictKind = "duplicate"
+	// ConflictShadowed is a route whose path is matched by the template of a route tried before it.
+	ConflictShadowed ConflictKind = "shadowed"
+)
+
+// RouteConflict reports a route which can never be reached because a route tried before it
+// matches all of its requests.
+type RouteConflict struct {
+	Kind  ConflictKind
+	Route RouteInfo
+	By    RouteInfo
+}
+
+func (c RouteConflict) String() string {
+	verb := "is shadowed by"
+	if c.Kind == ConflictDuplicate {
+		verb = "duplicates"
+	}
+	return fmt.Sprintf("route %s %s (handler %s) %s %s %s (handler %s)",
+		methodString(c.Route.Method), c.Route.Path, c.Route.Handler, verb,
+		methodString(c.By.Method), c.By.Path, c.By.Handler)
+}
+
+func methodString(method string) string {
+	if method == "" {
+		return "ANY"
+	}
+	return method
+}
+
+// WithStrictRoutes panics when a route is registered which conflicts with an earlier route,
+// otherwise the conflict is logged in DebugMode.
+func WithStrictRoutes() RouterOption {
+	return func(v *Prouter) {
+		v.strictRoutes = true
+	}
+}
+
+// RouteConflicts returns the conflicts found while the routes were registered.
+func (v *Prouter) RouteConflicts() []RouteConflict {
+	return slices.Clone(v.conflicts)
+}
+
+// templateShape replaces the variable names of a template, so that templates which only differ
+// by the names of their variables are equal.
+func templateShape(tpl string) string {
+	shape, ok := rewriteTemplate(tpl, func(s string) string { return s }, func(_, expr string) string {
+		return "{:" + varPattern(expr) + "}"
+	})
+	if !ok {
+		return tpl
+	}
+	return shape
+}
+
+func hasVars(tpl string) bool {
+	return strings.ContainsRune(tpl, '{')
+}
+
+// routeMatch is what a route matches besides its path and method, it is recorded when the
+// options of the route are applied.
+type routeMatch struct {
+	host    string
+	queries []string
+	// custom is set when an option added a matcher which can not be read back from the route,
+	// e.g. headers, schemes or a MatcherFunc
+	custom bool
+}
+
+// routeState is the part of a route which can be read back from gorilla/mux.
+type routeState struct {
+	name    string
+	path    string
+	host    string
+	queries []string
+	methods []string
+}
+
+func readRouteState(route *mux.Route) routeState {
+	s := routeState{name: route.GetName()}
+	s.path, _ = route.GetPathTemplate()
+	s.host, _ = route.GetHostTemplate()
+	s.queries, _ = route.GetQueriesTemplates()
+	s.methods, _ = route.GetMethods()
+	return s
+}
+
+func (s routeState) equal(o routeState) bool {
+	return s.name == o.name && s.path == o.path && s.host == o.host &&
+		slices.Equal(s.queries, o.queries) && slices.Equal(s.methods, o.methods)
+}
+
+// applyRouteOptions applies the options to the route and records what the route matches.
+// An option which changes nothing that can be read back is assumed to add a custom matcher.
+func applyRouteOptions(route *mux.Route, opts []RouteOption) (*mux.Route, routeMatch) {
+	var match routeMatch
+	for _, opt := range opts {
+		before := readRouteState(route)
+		route = opt(route)
+		if readRouteState(route).equal(before) {
+			match.custom = true
+		}
+	}
+
+	match.host, _ = route.GetHostTemplate()
+	match.queries, _ = route.GetQueriesTemplates()
+	return route, match
+}
+
+// sameScope reports whether the routes match the same hosts and queries apart from the path.
+// Routes with custom matchers, such as headers, are never in the same scope.
+func sameScope(a, b *routeEntry) bool {
+	if a.match.custom || b.match.custom {
+		return false
+	}
+	return a.match.host == b.match.host && slices.Equal(a.match.queries, b.match.queries)
+}
+
+var errWalkDone = errors.New("walk done")
+
+// firstMatched returns the route of a and b which is tried first by the router.
+func (v *Prouter) firstMatched(a, b *routeEntry) *routeEntry {
+	var first *routeEntry
+	_ = v.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		switch route {
+		case a.route:
+			first = a
+		case b.route:
+			first = b
+		default:
+			return nil
+		}
+		return errWalkDone
+	})
+	return first
+}
+
+// findConflict returns the route which takes the requests of entry. Routes with the same method and
+// a template of the same shape are duplicates, a static path is shadowed when a request to it
+// is matched by another route.
+func (v *Prouter) findConflict(entry *routeEntry) (RouteConflict, bool) {
+	if entry.match.custom {
+		return RouteConflict{}, false
+	}
+
+	for _, prev := range v.routeShapes[entry.shape] {
+		if prev.info.Method != "" && !strings.EqualFold(prev.info.Method, entry.info.Method) {
+			continue
+		}
+		if !sameScope(prev, entry) {
+			continue
+		}
+
+		if v.firstMatched(prev, entry) == entry {
+			return RouteConflict{Kind: ConflictDuplicate, Route: prev.info, By: entry.info}, true
+		}
+		return RouteConflict{Kind: ConflictDuplicate, Route: entry.info, By: prev.info}, true
+	}
+
+	if hasVars(entry.info.Path) {
+		return RouteConflict{}, false
+	}
+
+	method := entry.info.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	r := &http.Request{Method: method, URL: &url.URL{Path: entry.info.Path}, Host: entry.match.host, Header: make(http.Header)}
+	matched, _ := v.match(r)
+	if matched == nil || matched == entry || matched.match.custom {
+		return RouteConflict{}, false
+	}
+	// a route for any method is only shadowed by a route which takes every method too
+	if entry.info.Method == "" && matched.info.Method != "" {
+		return RouteConflict{}, false
+	}
+	return RouteConflict{Kind: ConflictShadowed, Route: entry.info, By: matched.info}, true
+}
+
+func (v *Prouter) checkConflict(entry *routeEntry) {
+	conflict, ok := v.findConflict(entry)
+	if !ok {
+		return
+	}
+
+	v.conflicts = append(v.conflicts, conflict)
+	if v.strictRoutes {
+		panic(conflict.String())
+	}
+	if prouterMode == DebugMode {
+		plog.Warnf("%s", conflict.String())
+	}
+}
@@ -0,0 +1,209 @@
+package prouter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRouteConflicts(t *testing.T) {
+	headers := func(pairs ...string) RouteOption {
+		return func(r *mux.Route) *mux.Route { return r.Headers(pairs...) }
+	}
+
+	tests := []struct {
+		name     string
+		opts     []RouterOption
+		setup    func(r *Prouter)
+		wantKind []ConflictKind
+	}{
+		{
+			name: "duplicate",
+			setup: func(r *Prouter) {
+				r.GET("/users/{id}", textHandler("a"))
+				r.GET("/users/{name}", textHandler("b"))
+			},
+			wantKind: []ConflictKind{ConflictDuplicate},
+		},
+		{
+			name: "duplicate in a group",
+			setup: func(r *Prouter) {
+				r.GET("/api/items", textHandler("a"))
+				r.Group("/api").GET("/items", textHandler("b"))
+			},
+			wantKind: []ConflictKind{ConflictDuplicate},
+		},
+		{
+			name: "different methods",
+			setup: func(r *Prouter) {
+				r.GET("/users/{id}", textHandler("a"))
+				r.PUT("/users/{id}", textHandler("b"))
+			},
+		},
+		{
+			name: "shadowed static path",
+			setup: func(r *Prouter) {
+				r.GET("/users/{id}", textHandler("a"))
+				r.GET("/users/me", textHandler("b"))
+			},
+			wantKind: []ConflictKind{ConflictShadowed},
+		},
+		{
+			name: "static path first",
+			setup: func(r *Prouter) {
+				r.GET("/users/me", textHandler("a"))
+				r.GET("/users/{id}", textHandler("b"))
+			},
+		},
+		{
+			name: "distinct headers",
+			setup: func(r *Prouter) {
+				r.GET("/items", textHandler("v1"), headers("Accept-Version", "1"))
+				r.GET("/items", textHandler("v2"), headers("Accept-Version", "2"))
+			},
+		},
+		{
+			name: "header route before a plain route",
+			setup: func(r *Prouter) {
+				r.GET("/users/{id}", textHandler("a"), headers("X-Admin", "1"))
+				r.GET("/users/me", textHandler("b"))
+			},
+		},
+		{
+			name: "scheme of the router",
+			opts: []RouterOption{WithScheme("https")},
+			setup: func(r *Prouter) {
+				r.GET("/items", textHandler("a"))
+				r.GET("/items", textHandler("b"))
+			},
+			wantKind: []ConflictKind{ConflictDuplicate},
+		},
+		{
+			name: "any route after a get route",
+			setup: func(r *Prouter) {
+				r.GET("/p/{id}", textHandler("a"))
+				r.Any("/p/hello", textHandler("b"))
+				r.GET("/q", textHandler("c"))
+				r.Any("/q", textHandler("d"))
+			},
+		},
+		{
+			name: "any route after an any route",
+			setup: func(r *Prouter) {
+				r.Any("/p/{id}", textHandler("a"))
+				r.Any("/p/hello", textHandler("b"))
+			},
+			wantKind: []ConflictKind{ConflictShadowed},
+		},
+		{
+			name: "get route after an any route",
+			setup: func(r *Prouter) {
+				r.Any("/p/{id}", textHandler("a"))
+				r.GET("/p/hello", textHandler("b"))
+				r.Any("/q", textHandler("c"))
+				r.GET("/q", textHandler("d"))
+			},
+			wantKind: []ConflictKind{ConflictShadowed, ConflictDuplicate},
+		},
+		{
+			name: "hosts",
+			setup: func(r *Prouter) {
+				host := func(h string) RouteOption {
+					return func(r *mux.Route) *mux.Route { return r.Host(h) }
+				}
+				r.GET("/items", textHandler("a"), host("a.example.com"))
+				r.GET("/items", textHandler("b"), host("b.example.com"))
+				r.GET("/items", textHandler("c"))
+				r.GET("/items/{id}", textHandler("d"), host("a.example.com"))
+				r.GET("/items/me", textHandler("e"))
+				r.GET("/items/{name}", textHandler("f"), host("a.example.com"))
+			},
+			wantKind: []ConflictKind{ConflictDuplicate},
+		},
+		{
+			name: "queries",
+			setup: func(r *Prouter) {
+				query := func(pairs ...string) RouteOption {
+					return func(r *mux.Route) *mux.Route { return r.Queries(pairs...) }
+				}
+				r.GET("/items", textHandler("a"), query("v", "1"))
+				r.GET("/items", textHandler("b"), query("v", "2"))
+				r.GET("/items", textHandler("c"), query("v", "1"))
+			},
+			wantKind: []ConflictKind{ConflictDuplicate},
+		},
+		{
+			name: "route name",
+			setup: func(r *Prouter) {
+				r.GET("/items", textHandler("a"), WithRouteName("items"))
+				r.GET("/items", textHandler("b"), WithRouteName("items2"))
+			},
+			wantKind: []ConflictKind{ConflictDuplicate},
+		},
+		{
+			name: "matcher func",
+			setup: func(r *Prouter) {
+				r.GET("/items", textHandler("a"), func(r *mux.Route) *mux.Route {
+					return r.MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool { return req.ContentLength > 0 })
+				})
+				r.GET("/items", textHandler("b"))
+			},
+		},
+		{
+			name: "routes of a router",
+			setup: func(r *Prouter) {
+				r.HandleRouter(testRouter{
+					NewRoute(http.MethodGet, "/items", textHandler("a"), WithRouteName("items")),
+					NewRoute(http.MethodGet, "/items", textHandler("b"), headers("X-Admin", "1")),
+					NewRoute(http.MethodGet, "/items", textHandler("c")),
+				})
+			},
+			wantKind: []ConflictKind{ConflictDuplicate},
+		},
+		{
+			name: "different shapes",
+			setup: func(r *Prouter) {
+				r.GET("/users/{id}", textHandler("a"))
+				r.GET("/users/{id}/posts", textHandler("b"))
+				r.GET("/users/{id:[0-9]+}", textHandler("c"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.opts...)
+			tt.setup(r)
+
+			conflicts := r.RouteConflicts()
+			if len(conflicts) != len(tt.wantKind) {
+				t.Fatalf("RouteConflicts() = %v, want %v", conflicts, tt.wantKind)
+			}
+			for i, c := range conflicts {
+				if c.Kind != tt.wantKind[i] {
+					t.Errorf("conflict %d = %s, want %s", i, c, tt.wantKind[i])
+				}
+			}
+		})
+	}
+}
+
+type testRouter []Route
+
+func (r testRouter) Routes() []Route { return r }
+
+func TestStrictRoutes(t *testing.T) {
+	r := New(WithStrictRoutes())
+	r.GET("/p/{id}", textHandler("id"))
+	r.Any("/p/hello", textHandler("hello"))
+	r.GET("/items", textHandler("v1"), func(route *mux.Route) *mux.Route { return route.Headers("Accept-Version", "1") })
+	r.GET("/items", textHandler("v2"))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a duplicate route did not panic")
+		}
+	}()
+	r.HandleRoute(http.MethodGet, "/items", textHandler("v3"))
+}
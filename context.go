@@ -14,6 +14,8 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/go-puzzles/puzzles/plog"
@@ -24,22 +26,105 @@ type ContextKeyType int
 const (
 	ContextRequestKey ContextKeyType = iota
 	ContextKey
+	ContextRequestIDKey
 )
 
+// Context is reused for another request after the handler returns, use Copy to hand it
+// to a goroutine which outlives the handler.
 type Context struct {
 	context.Context
 	router *Prouter
 	vars   map[string]string
-
-	Request  *http.Request
-	Writer   *ResponseWriter
+	// params are the variables converted by the converters of the route
+	params map[string]any
+	// query and bodyCache are parsed and read once by the accessors, bodyLimited is set once
+	// the body is capped by limitBody
+	query       url.Values
+	bodyCache   []byte
+	bodyLimited bool
+
+	Request *http.Request
+	Writer  *ResponseWriter
+	// Path is the path of the request followed by its query
 	Path     string
 	ClientIp string
 	Method   string
 
+	// writer is the storage of Writer
+	writer ResponseWriter
+
 	session *Session
 
-	startTime time.Time
+	startTime   time.Time
+	handlerName string
+	// route is the path template of the matched route, e.g. /user/{id}
+	route string
+	// finishHooks run after the response has been written
+	finishHooks []func()
+	// bodyCapture is set by BodyLogMiddleware
+	bodyCapture *capturedBody
+	requestID   string
+}
+
+// reset prepares a pooled context for the request, the finish hooks keep their capacity.
+func (c *Context) reset(v *Prouter, w http.ResponseWriter, r *http.Request) {
+	clear(c.finishHooks)
+	*c = Context{
+		router:      v,
+		Path:        pathWithQuery(r),
+		Method:      r.Method,
+		ClientIp:    v.proxyResolver.ClientIP(r),
+		writer:      ResponseWriter{passthroughWriter{w}, http.StatusOK, noWritten},
+		startTime:   time.Now(),
+		finishHooks: c.finishHooks[:0],
+	}
+	c.Writer = &c.writer
+}
+
+func pathWithQuery(r *http.Request) string {
+	if raw := r.URL.RawQuery; raw != "" {
+		return r.URL.Path + "?" + raw
+	}
+	return r.URL.Path
+}
+
+// Copy returns a copy of the context which can be used after the handler returns.
+// The copy can not write the response, its Request is a shallow copy of the request.
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Context:     c.Context,
+		router:      c.router,
+		vars:        c.vars,
+		params:      c.params,
+		query:       c.query,
+		bodyCache:   c.bodyCache,
+		bodyLimited: c.bodyLimited,
+		Request:     c.Request,
+		Path:        c.Path,
+		ClientIp:    c.ClientIp,
+		Method:      c.Method,
+		session:     c.session,
+		startTime:   c.startTime,
+		handlerName: c.handlerName,
+		route:       c.route,
+		requestID:   c.requestID,
+	}
+	if c.Request != nil {
+		// the fields of the request, e.g. the body, may still be replaced by the handler
+		cp.Request = c.Request.WithContext(c.Request.Context())
+	}
+	return cp
+}
+
+// onFinish registers fn to be run after the response has been written.
+func (c *Context) onFinish(fn func()) {
+	c.finishHooks = append(c.finishHooks, fn)
+}
+
+func (c *Context) finish() {
+	for _, fn := range slices.Backward(c.finishHooks) {
+		fn()
+	}
 }
 
 func (c *Context) Ctx() context.Context {
@@ -50,6 +135,20 @@ func (c *Context) Var(key string) string {
 	return c.vars[key]
 }
 
+// RouteTemplate returns the path template the request matched instead of the raw path.
+func (c *Context) RouteTemplate() string {
+	return c.route
+}
+
+func (c *Context) HandlerName() string {
+	return c.handlerName
+}
+
+// RequestID returns the request id set by RequestIDMiddleware.
+func (c *Context) RequestID() string {
+	return c.requestID
+}
+
 func (c *Context) WithValue(key, val any) {
 	c.Context = context.WithValue(c.Context, key, val)
 }
@@ -79,7 +178,52 @@ func (c *Context) ExecuteTemplateFS(fs embed.FS, resource string, data any) (Res
 	return nil, nil
 }
 
+// Scheme returns the scheme used by the client, it honours the forwarding headers of trusted proxies.
+func (c *Context) Scheme() string {
+	return c.router.proxyResolver.Scheme(c.Request)
+}
+
+// Host returns the host requested by the client, it honours the forwarding headers of trusted proxies.
+func (c *Context) Host() string {
+	return c.router.proxyResolver.Host(c.Request)
+}
+
+// BaseURL returns the external scheme and host of the request, e.g. https://example.com.
+func (c *Context) BaseURL() string {
+	return c.Scheme() + "://" + c.Host()
+}
+
+// absoluteURL makes a path relative location absolute with the external base url.
+func (c *Context) absoluteURL(location string) string {
+	u, err := url.Parse(location)
+	if err != nil || u.IsAbs() || u.Host != "" {
+		return location
+	}
+
+	base := &url.URL{Scheme: c.Scheme(), Host: c.Host(), Path: c.Request.URL.Path}
+	return base.ResolveReference(u).String()
+}
+
+// URLFor builds the absolute url of a named route, see WithRouteName.
+func (c *Context) URLFor(name string, pairs ...string) (string, error) {
+	u, err := c.router.URL(name, pairs...)
+	if err != nil {
+		return "", err
+	}
+	return c.absoluteURL(u.String()), nil
+}
+
+// redirectLocation resolves a relative redirect against the external base url when the request came
+// through a trusted proxy, so that it does not point at the internal host. Otherwise it is kept as it is.
+func (c *Context) redirectLocation(location string) string {
+	if !c.router.proxyResolver.fromTrustedProxy(c.Request) {
+		return location
+	}
+	return c.absoluteURL(location)
+}
+
+// Redirect replies with a redirect to location, see redirectLocation.
 func (c *Context) Redirect(code int, location string) (Response, error) {
-	http.Redirect(c.Writer, c.Request, location, code)
+	http.Redirect(c.Writer, c.Request, c.redirectLocation(location), code)
 	return nil, nil
 }
@@ -0,0 +1,245 @@
+package prouter
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	defaultCorsMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete,
+	}
+	defaultCorsHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"}
+)
+
+// CorsMiddleware implements Cross-Origin Resource Sharing. Preflight requests are answered
+// for every registered path even if it has no OPTIONS route, since requests whose method
+// does not match run through the middlewares of the group as well.
+type CorsMiddleware struct {
+	allowAllOrigins  bool
+	origins          map[string]struct{}
+	wildcardOrigins  [][2]string
+	originRegexps    []*regexp.Regexp
+	originFunc       func(origin string) bool
+	allowMethods     []string
+	allowAllHeaders  bool
+	allowHeaders     []string
+	exposeHeaders    []string
+	allowCredentials bool
+	maxAge           time.Duration
+}
+
+type CorsOption func(*CorsMiddleware)
+
+// WithCorsOrigins sets the allowed origins. "*" allows every origin, and an origin
+// containing one "*" is a wildcard, e.g. https://*.example.com.
+func WithCorsOrigins(origins ...string) CorsOption {
+	return func(m *CorsMiddleware) {
+		for _, origin := range origins {
+			origin = strings.ToLower(origin)
+			switch {
+			case origin == "*":
+				m.allowAllOrigins = true
+			case strings.Count(origin, "*") == 1:
+				prefix, suffix, _ := strings.Cut(origin, "*")
+				m.wildcardOrigins = append(m.wildcardOrigins, [2]string{prefix, suffix})
+			default:
+				m.origins[origin] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithCorsOriginRegex allows the origins matching one of the patterns, it panics on an invalid pattern.
+// A pattern must match the whole origin, e.g. https://[a-z]+\.example\.com does not allow
+// https://a.example.com.evil.com.
+func WithCorsOriginRegex(patterns ...string) CorsOption {
+	return func(m *CorsMiddleware) {
+		for _, pattern := range patterns {
+			m.originRegexps = append(m.originRegexps, regexp.MustCompile("^(?:"+pattern+")$"))
+		}
+	}
+}
+
+// WithCorsOriginFunc allows the origins for which fn returns true.
+func WithCorsOriginFunc(fn func(origin string) bool) CorsOption {
+	return func(m *CorsMiddleware) {
+		m.originFunc = fn
+	}
+}
+
+func WithCorsMethods(methods ...string) CorsOption {
+	return func(m *CorsMiddleware) {
+		m.allowMethods = nil
+		for _, method := range methods {
+			m.allowMethods = append(m.allowMethods, strings.ToUpper(method))
+		}
+	}
+}
+
+// WithCorsHeaders sets the allowed request headers, "*" allows every requested header.
+func WithCorsHeaders(headers ...string) CorsOption {
+	return func(m *CorsMiddleware) {
+		m.allowHeaders = nil
+		for _, header := range headers {
+			if header == "*" {
+				m.allowAllHeaders = true
+				continue
+			}
+			m.allowHeaders = append(m.allowHeaders, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+func WithCorsExposeHeaders(headers ...string) CorsOption {
+	return func(m *CorsMiddleware) {
+		m.exposeHeaders = append(m.exposeHeaders, headers...)
+	}
+}
+
+// WithCorsCredentials allows the requests with credentials, it requires the allowed origins
+// to be listed since every origin could read the responses of the user otherwise.
+func WithCorsCredentials(allow bool) CorsOption {
+	return func(m *CorsMiddleware) {
+		m.allowCredentials = allow
+	}
+}
+
+// WithCorsMaxAge sets how long the result of a preflight request can be cached.
+func WithCorsMaxAge(d time.Duration) CorsOption {
+	return func(m *CorsMiddleware) {
+		m.maxAge = d
+	}
+}
+
+// NewCorsMiddleware allows every origin with the common methods and headers unless configured.
+// It panics if every origin is allowed together with credentials.
+func NewCorsMiddleware(opts ...CorsOption) *CorsMiddleware {
+	m := &CorsMiddleware{
+		origins:      make(map[string]struct{}),
+		allowMethods: defaultCorsMethods,
+		allowHeaders: defaultCorsHeaders,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if len(m.origins) == 0 && len(m.wildcardOrigins) == 0 && len(m.originRegexps) == 0 && m.originFunc == nil {
+		m.allowAllOrigins = true
+	}
+	if m.allowAllOrigins && m.allowCredentials {
+		panic("cors: credentials can not be allowed for every origin")
+	}
+
+	return m
+}
+
+func (m *CorsMiddleware) allowOrigin(origin string) bool {
+	if m.allowAllOrigins {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if _, ok := m.origins[lower]; ok {
+		return true
+	}
+	for _, w := range m.wildcardOrigins {
+		if len(lower) >= len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	for _, re := range m.originRegexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return m.originFunc != nil && m.originFunc(origin)
+}
+
+func (m *CorsMiddleware) setOrigin(header http.Header, origin string) {
+	if m.allowAllOrigins {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Add("Vary", "Origin")
+	}
+
+	if m.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (m *CorsMiddleware) preflight(ctx *Context, origin string) (Response, error) {
+	header := ctx.Writer.Header()
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(ctx.Request.Header.Get("Access-Control-Request-Method"))
+	if !m.allowOrigin(origin) || !slices.Contains(m.allowMethods, method) {
+		return nil, MsgError(http.StatusForbidden, "cors request not allowed").
+			SetComponent(ErrProuter).
+			SetResponseType(Forbidden)
+	}
+
+	requested := ctx.Request.Header.Get("Access-Control-Request-Headers")
+	if requested != "" && !m.allowAllHeaders {
+		for _, h := range splitList([]string{requested}) {
+			if !slices.Contains(m.allowHeaders, http.CanonicalHeaderKey(h)) {
+				return nil, MsgError(http.StatusForbidden, "cors header not allowed: "+h).
+					SetComponent(ErrProuter).
+					SetResponseType(Forbidden)
+			}
+		}
+	}
+
+	m.setOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(m.allowMethods, ", "))
+	switch {
+	case requested == "":
+	case m.allowAllHeaders:
+		header.Set("Access-Control-Allow-Headers", requested)
+	default:
+		header.Set("Access-Control-Allow-Headers", strings.Join(m.allowHeaders, ", "))
+	}
+
+	if m.maxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(m.maxAge/time.Second)))
+	}
+
+	ctx.Writer.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+func (m *CorsMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		origin := ctx.Request.Header.Get("Origin")
+		if origin == "" {
+			return handler.Handle(ctx)
+		}
+
+		if isPreflight(ctx.Request) {
+			return m.preflight(ctx, origin)
+		}
+
+		if m.allowOrigin(origin) {
+			header := ctx.Writer.Header()
+			m.setOrigin(header, origin)
+			if len(m.exposeHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(m.exposeHeaders, ", "))
+			}
+		}
+
+		return handler.Handle(ctx)
+	})
+}
@@ -0,0 +1,221 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCorsAllowOrigin(t *testing.T) {
+	m := NewCorsMiddleware(
+		WithCorsOrigins("https://app.example.com", "https://*.example.org"),
+		WithCorsOriginRegex(`https://[a-z]+\.test`, `https://a\.example\.net|https://b\.example\.net`),
+		WithCorsOriginFunc(func(origin string) bool { return origin == "null" }),
+	)
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://app.example.com", want: true},
+		{origin: "HTTPS://APP.EXAMPLE.COM", want: true},
+		{origin: "https://evil.example.com", want: false},
+		{origin: "https://a.example.org", want: true},
+		{origin: "https://example.org", want: false},
+		{origin: "https://a.example.org.evil.com", want: false},
+		{origin: "https://dev.test", want: true},
+		{origin: "https://dev1.test", want: false},
+		{origin: "https://dev.test.evil.com", want: false},
+		{origin: "https://evil.com/https://dev.test", want: false},
+		{origin: "https://a.example.net", want: true},
+		{origin: "https://b.example.net.evil.com", want: false},
+		{origin: "null", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := m.allowOrigin(tt.origin); got != tt.want {
+			t.Errorf("allowOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	if !NewCorsMiddleware().allowOrigin("https://any.example.com") {
+		t.Error("the default middleware does not allow every origin")
+	}
+}
+
+func TestCorsCredentialsForAllOrigins(t *testing.T) {
+	for name, opts := range map[string][]CorsOption{
+		"default origins": {WithCorsCredentials(true)},
+		"any origin":      {WithCorsOrigins("https://app.example.com", "*"), WithCorsCredentials(true)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("NewCorsMiddleware did not panic")
+				}
+			}()
+			NewCorsMiddleware(opts...)
+		})
+	}
+}
+
+func TestCorsPreflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []CorsOption
+		path        string
+		origin      string
+		method      string
+		headers     string
+		wantCode    int
+		wantOrigin  string
+		wantHeaders string
+		wantMaxAge  string
+		wantCreds   bool
+	}{
+		{
+			name:       "path without options route",
+			path:       "/api/items",
+			origin:     "https://app.example.com",
+			method:     http.MethodPost,
+			wantCode:   http.StatusNoContent,
+			wantOrigin: "*",
+		},
+		{
+			name:     "method not allowed",
+			path:     "/api/items",
+			origin:   "https://app.example.com",
+			method:   "PROPFIND",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "origin not allowed",
+			opts:     []CorsOption{WithCorsOrigins("https://app.example.com")},
+			path:     "/api/items",
+			origin:   "https://evil.example.com",
+			method:   http.MethodGet,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:        "allowed headers",
+			path:        "/api/items",
+			origin:      "https://app.example.com",
+			method:      http.MethodPut,
+			headers:     "content-type, authorization",
+			wantCode:    http.StatusNoContent,
+			wantOrigin:  "*",
+			wantHeaders: "Origin, Content-Type, Accept, Authorization, X-Requested-With",
+		},
+		{
+			name:     "header not allowed",
+			path:     "/api/items",
+			origin:   "https://app.example.com",
+			method:   http.MethodPut,
+			headers:  "X-Custom",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:        "any header",
+			opts:        []CorsOption{WithCorsHeaders("*")},
+			path:        "/api/items",
+			origin:      "https://app.example.com",
+			method:      http.MethodPut,
+			headers:     "X-Custom",
+			wantCode:    http.StatusNoContent,
+			wantOrigin:  "*",
+			wantHeaders: "X-Custom",
+		},
+		{
+			name: "credentials and max age",
+			opts: []CorsOption{
+				WithCorsOrigins("https://*.example.com"), WithCorsCredentials(true), WithCorsMaxAge(10 * time.Minute),
+			},
+			path:       "/api/items",
+			origin:     "https://app.example.com",
+			method:     http.MethodGet,
+			wantCode:   http.StatusNoContent,
+			wantOrigin: "https://app.example.com",
+			wantMaxAge: "600",
+			wantCreds:  true,
+		},
+		{
+			name:     "path of another group",
+			path:     "/public/items",
+			origin:   "https://app.example.com",
+			method:   http.MethodPost,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			api := r.Group("/api")
+			api.UseMiddleware(NewCorsMiddleware(tt.opts...))
+			api.GET("/items", func(ctx *Context) (Response, error) { return ctx.NoContent() })
+			r.Group("/public").GET("/items", func(ctx *Context) (Response, error) { return ctx.NoContent() })
+
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			header := w.Header()
+			if got := header.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := header.Get("Access-Control-Allow-Headers"); got != tt.wantHeaders {
+				t.Errorf("Allow-Headers = %q, want %q", got, tt.wantHeaders)
+			}
+			if got := header.Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("Max-Age = %q, want %q", got, tt.wantMaxAge)
+			}
+			if got := header.Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCreds {
+				t.Errorf("Allow-Credentials = %v, want %v", got, tt.wantCreds)
+			}
+		})
+	}
+}
+
+func TestCorsSimpleRequest(t *testing.T) {
+	r := New()
+	r.UseMiddleware(NewCorsMiddleware(
+		WithCorsOrigins("https://app.example.com"),
+		WithCorsExposeHeaders("X-Total"),
+	))
+	r.GET("/items", func(ctx *Context) (Response, error) { return ctx.NoContent() })
+
+	for _, tt := range []struct {
+		origin     string
+		wantOrigin string
+	}{
+		{origin: "https://app.example.com", wantOrigin: "https://app.example.com"},
+		{origin: "https://evil.example.com"},
+		{origin: ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("origin %q: status = %d, the handler did not run", tt.origin, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+			t.Errorf("origin %q: Allow-Origin = %q, want %q", tt.origin, got, tt.wantOrigin)
+		}
+		if tt.wantOrigin != "" && w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+			t.Errorf("origin %q: Expose-Headers = %q", tt.origin, w.Header().Get("Access-Control-Expose-Headers"))
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package prouter
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// Engine finds the route of a request instead of the linear matching of gorilla/mux.
+// The routes are registered on the mux router as well, which builds the urls of named routes
+// and handles the requests the engine has no route for, e.g. with a 404 or 405 response.
+// A group with a NotFound handler takes the requests under its prefix which none of its routes
+// match, also the ones of routes registered outside of it afterwards, like with gorilla/mux.
+type Engine interface {
+	// Handle adds a route, the routes are added in the order they are tried by the mux router
+	// and the first matching route wins.
+	Handle(route EngineRoute)
+	// Lookup returns the handler and the variables of the route matching r.
+	Lookup(r *http.Request) (http.Handler, map[string]string, bool)
+}
+
+// EngineRoute is a route added to an Engine.
+type EngineRoute struct {
+	// Method is empty for a route matching any method
+	Method string
+	// Path is the full path template, e.g. /users/{id:int}
+	Path string
+	// EncodedPath routes are matched against the encoded path of the request
+	EncodedPath bool
+	// Match checks every matcher of the route, such as the host, and returns its handler and variables.
+	// The engine only has to narrow the routes down by the path.
+	Match func(r *http.Request) (http.Handler, map[string]string, bool)
+}
+
+// WithEngine matches the requests with an engine created by newEngine, e.g. NewRadixEngine.
+// The engine is built on the first request and rebuilt when routes are added afterwards.
+func WithEngine(newEngine func() Engine) RouterOption {
+	return func(v *Prouter) {
+		v.newEngine = newEngine
+	}
+}
+
+// engineState holds the engine built from the routes registered so far.
+type engineState struct {
+	mu     sync.Mutex
+	engine atomic.Pointer[Engine]
+	dirty  atomic.Bool
+}
+
+// muxMatcher matches the route like the mux router does. groups are the subrouters with a NotFound
+// handler tried before the route, the route is only reached when none of them takes the request.
+func muxMatcher(route *mux.Route, groups []*mux.Route) func(r *http.Request) (http.Handler, map[string]string, bool) {
+	return func(r *http.Request) (http.Handler, map[string]string, bool) {
+		var match mux.RouteMatch
+		for _, g := range groups {
+			if g.Match(r, &match) {
+				return match.Handler, match.Vars, true
+			}
+			match = mux.RouteMatch{}
+		}
+
+		if !route.Match(r, &match) || match.MatchErr != nil {
+			return nil, nil, false
+		}
+		return match.Handler, match.Vars, true
+	}
+}
+
+// currentEngine returns the engine, it is built from the routes in the order of the mux router.
+func (v *Prouter) currentEngine() Engine {
+	if e := v.engineState.engine.Load(); e != nil && !v.engineState.dirty.Load() {
+		return *e
+	}
+
+	v.engineState.mu.Lock()
+	defer v.engineState.mu.Unlock()
+	if e := v.engineState.engine.Load(); e != nil && !v.engineState.dirty.Load() {
+		return *e
+	}
+
+	engine := v.newEngine()
+	var notFoundGroups []*mux.Route
+	_ = v.router.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		if g, ok := v.groupRoutes[route]; ok && g.router.NotFoundHandler != nil {
+			notFoundGroups = append(notFoundGroups, route)
+			return nil
+		}
+
+		entry, ok := v.routeIndex[route]
+		if !ok {
+			return nil
+		}
+		var groups []*mux.Route
+		for _, g := range notFoundGroups {
+			if !slices.Contains(ancestors, g) {
+				groups = append(groups, g)
+			}
+		}
+		engine.Handle(EngineRoute{
+			Method:      entry.info.Method,
+			Path:        entry.info.Path,
+			EncodedPath: entry.policy.encodedPath,
+			Match:       muxMatcher(route, groups),
+		})
+		return nil
+	})
+
+	v.engineState.dirty.Store(false)
+	v.engineState.engine.Store(&engine)
+	return engine
+}
+
+// serveEngine serves r with the route found by the engine, it reports false when there is none.
+func (v *Prouter) serveEngine(w http.ResponseWriter, r *http.Request) bool {
+	if v.newEngine == nil {
+		return false
+	}
+
+	h, vars, ok := v.currentEngine().Lookup(r)
+	if !ok {
+		return false
+	}
+	h.ServeHTTP(w, mux.SetURLVars(r, vars))
+	return true
+}
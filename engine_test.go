@@ -0,0 +1,273 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// engines are the ways the router matches the requests, every route table must be served the same by all of them.
+var engines = []struct {
+	name string
+	opts []RouterOption
+}{
+	{name: "mux"},
+	{name: "radix", opts: []RouterOption{WithEngine(NewRadixEngine)}},
+}
+
+type engineRequest struct {
+	method   string
+	target   string
+	host     string
+	wantCode int
+	wantBody string
+}
+
+func TestEngineRouteTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []RouterOption
+		setup    func(r *Prouter)
+		requests []engineRequest
+	}{
+		{
+			name: "static and variables",
+			setup: func(r *Prouter) {
+				r.GET("/users/me", textHandler("me"))
+				r.GET("/users/{id}", varHandler("id"))
+				r.GET("/users/{id}/posts/{post:int}", varHandler("post"))
+				r.GET("/files/{path:.*}", varHandler("path"))
+			},
+			requests: []engineRequest{
+				{target: "/users/me", wantCode: 200, wantBody: "me"},
+				{target: "/users/42", wantCode: 200, wantBody: "42"},
+				{target: "/users/42/posts/7", wantCode: 200, wantBody: "7"},
+				{target: "/users/42/posts/x", wantCode: 404},
+				{target: "/files/a/b/c.txt", wantCode: 200, wantBody: "a/b/c.txt"},
+				{target: "/missing", wantCode: 404},
+			},
+		},
+		{
+			name: "order of registration",
+			setup: func(r *Prouter) {
+				r.GET("/items/{id}", textHandler("item"))
+				r.GET("/items/new", textHandler("new"))
+			},
+			requests: []engineRequest{
+				// the route registered first wins even though the other one is static
+				{target: "/items/new", wantCode: 200, wantBody: "item"},
+				{target: "/items/42", wantCode: 200, wantBody: "item"},
+			},
+		},
+		{
+			name: "methods",
+			setup: func(r *Prouter) {
+				r.GET("/items", textHandler("list"))
+				r.POST("/items", textHandler("create"))
+				r.HandleRoute("put", "/items", textHandler("replace"))
+			},
+			requests: []engineRequest{
+				{method: http.MethodPost, target: "/items", wantCode: 200, wantBody: "create"},
+				{method: http.MethodPut, target: "/items", wantCode: 200, wantBody: "replace"},
+				{method: http.MethodDelete, target: "/items", wantCode: 405},
+				{method: http.MethodHead, target: "/items", wantCode: 200},
+			},
+		},
+		{
+			name: "group method mismatch falls through",
+			setup: func(r *Prouter) {
+				r.Group("/api").GET("/items", textHandler("api-get"))
+				r.POST("/api/items", textHandler("root-post"))
+			},
+			requests: []engineRequest{
+				{target: "/api/items", wantCode: 200, wantBody: "api-get"},
+				{method: http.MethodPost, target: "/api/items", wantCode: 200, wantBody: "root-post"},
+				{method: http.MethodDelete, target: "/api/items", wantCode: 405},
+			},
+		},
+		{
+			name: "group not found",
+			setup: func(r *Prouter) {
+				r.GET("/api/before", textHandler("root-before"))
+				api := r.Group("/api")
+				api.NotFound(func(ctx *Context) (Response, error) {
+					return ctx.String(http.StatusNotFound, "api-404")
+				})
+				api.GET("/a", textHandler("api-a"))
+				api.Group("/v1").GET("/c", textHandler("v1-c"))
+				r.GET("/api/b", textHandler("root-b"))
+				r.GET("/other", textHandler("other"))
+			},
+			requests: []engineRequest{
+				{target: "/api/before", wantCode: 200, wantBody: "root-before"},
+				{target: "/api/a", wantCode: 200, wantBody: "api-a"},
+				{target: "/api/v1/c", wantCode: 200, wantBody: "v1-c"},
+				{target: "/api/b", wantCode: 404, wantBody: "api-404"},
+				{target: "/other", wantCode: 200, wantBody: "other"},
+			},
+		},
+		{
+			name: "not found set after the routes",
+			setup: func(r *Prouter) {
+				api := r.Group("/api")
+				api.GET("/a", textHandler("api-a"))
+				r.GET("/api/b", textHandler("root-b"))
+				r.POST("/api/a", textHandler("root-post-a"))
+				api.NotFound(func(ctx *Context) (Response, error) {
+					return ctx.String(http.StatusNotFound, "api-404")
+				})
+			},
+			requests: []engineRequest{
+				{target: "/api/b", wantCode: 404, wantBody: "api-404"},
+				{method: http.MethodPost, target: "/api/a", wantCode: 200, wantBody: "root-post-a"},
+			},
+		},
+		{
+			name: "host",
+			setup: func(r *Prouter) {
+				r.GET("/", textHandler("any"), func(route *mux.Route) *mux.Route { return route.Host("api.example.com") })
+				r.GET("/", textHandler("fallback"))
+			},
+			requests: []engineRequest{
+				{target: "/", host: "api.example.com", wantCode: 200, wantBody: "any"},
+				{target: "/", host: "www.example.com", wantCode: 200, wantBody: "fallback"},
+			},
+		},
+		{
+			name: "encoded path",
+			opts: []RouterOption{WithEncodedPath()},
+			setup: func(r *Prouter) {
+				r.GET("/files/{name}", varHandler("name"))
+			},
+			requests: []engineRequest{
+				{target: "/files/a%2Fb", wantCode: 200, wantBody: "a%2Fb"},
+			},
+		},
+	}
+
+	for _, engine := range engines {
+		for _, tt := range tests {
+			t.Run(engine.name+"/"+tt.name, func(t *testing.T) {
+				r := New(append(tt.opts, engine.opts...)...)
+				tt.setup(r)
+
+				for _, req := range tt.requests {
+					method := req.method
+					if method == "" {
+						method = http.MethodGet
+					}
+					hr := httptest.NewRequest(method, req.target, nil)
+					if req.host != "" {
+						hr.Host = req.host
+					}
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, hr)
+
+					if w.Code != req.wantCode {
+						t.Errorf("%s %s: status = %d, want %d: %s", method, req.target, w.Code, req.wantCode, w.Body)
+						continue
+					}
+					if req.wantBody != "" && w.Body.String() != req.wantBody {
+						t.Errorf("%s %s: body = %q, want %q", method, req.target, w.Body, req.wantBody)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRadixEngineMethod(t *testing.T) {
+	e := NewRadixEngine()
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	e.Handle(EngineRoute{
+		Method: "get",
+		Path:   "/items",
+		Match: func(r *http.Request) (http.Handler, map[string]string, bool) {
+			return handler, nil, true
+		},
+	})
+
+	if _, _, ok := e.Lookup(httptest.NewRequest(http.MethodGet, "/items", nil)); !ok {
+		t.Error("a route added with a lowercase method does not match")
+	}
+	if _, _, ok := e.Lookup(httptest.NewRequest(http.MethodPost, "/items", nil)); ok {
+		t.Error("a route matches another method")
+	}
+}
+
+func TestEngineRebuiltByNotFound(t *testing.T) {
+	r := New(WithEngine(NewRadixEngine))
+	api := r.Group("/api")
+	api.GET("/a", textHandler("api-a"))
+	r.GET("/api/b", textHandler("root-b"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/b", nil))
+	if w.Body.String() != "root-b" {
+		t.Fatalf("body = %q, want root-b", w.Body)
+	}
+
+	api.NotFound(func(ctx *Context) (Response, error) {
+		return ctx.String(http.StatusNotFound, "api-404")
+	})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/b", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "api-404" {
+		t.Errorf("status = %d, body = %q, want the not found handler of the group", w.Code, w.Body)
+	}
+}
+
+// newBenchRoutes registers 800 routes, 8 for each of 100 resources.
+func newBenchRoutes(r *Prouter) {
+	handler := func(ctx *Context) (Response, error) {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return nil, nil
+	}
+	for i := 0; i < 100; i++ {
+		res := "/res" + strconv.Itoa(i)
+		r.GET(res, handler)
+		r.POST(res, handler)
+		r.GET(res+"/{id}", handler)
+		r.PUT(res+"/{id}", handler)
+		r.DELETE(res+"/{id}", handler)
+		r.GET(res+"/{id}/items", handler)
+		r.GET(res+"/{id}/items/{item}", handler)
+		r.GET(res+"/{id}/files/{path:.*}", handler)
+	}
+}
+
+// BenchmarkEngines compares the engines on a table of 800 routes, gorilla/mux tries the routes
+// in order so the last ones cost it the most.
+func BenchmarkEngines(b *testing.B) {
+	targets := []struct {
+		name   string
+		target string
+	}{
+		{name: "first", target: "/res0/1"},
+		{name: "middle", target: "/res50/1/items/2"},
+		{name: "last", target: "/res99/1/files/a/b.txt"},
+		{name: "not found", target: "/missing"},
+	}
+
+	for _, engine := range engines {
+		setReleaseMode(b)
+		r := New(engine.opts...)
+		newBenchRoutes(r)
+
+		for _, tt := range targets {
+			b.Run(engine.name+"/"+tt.name, func(b *testing.B) {
+				req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+				w := &discardWriter{header: make(http.Header)}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					r.ServeHTTP(w, req)
+				}
+			})
+		}
+	}
+}
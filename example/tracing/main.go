@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/plog"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func helloHandler(ctx *prouter.Context) (prouter.Response, error) {
+	return prouter.SuccessResponse("hello " + ctx.Var("name")), nil
+}
+
+func main() {
+	// the in-memory exporter keeps the finished spans, replace it with an otlp exporter in production
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	router := prouter.New()
+	router.UseMiddleware(
+		prouter.NewTracingMiddleware(prouter.WithTracerProvider(provider)),
+		prouter.NewLogMiddleware(),
+		prouter.NewRecoveryMiddleware(),
+	)
+	router.GET("/hello/{name}", helloHandler)
+	router.GET("/spans", func(ctx *prouter.Context) (prouter.Response, error) {
+		var names []string
+		for _, span := range exporter.GetSpans() {
+			names = append(names, span.Name+" "+span.SpanContext.TraceID().String())
+		}
+		return prouter.SuccessResponse(names), nil
+	})
+
+	plog.PanicError(router.Run(":8080"))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/go-puzzles/prouter"
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+func echoHandler(ctx *prouter.Context, conn *prouter.WSConn) error {
+	for {
+		msg, err := prouter.WSReadJSON[Message](conn)
+		if err != nil {
+			return err
+		}
+
+		if err := prouter.WSWriteJSON(conn, msg); err != nil {
+			return err
+		}
+	}
+}
+
+func main() {
+	router := prouter.NewProuter()
+	router.WS("/echo", echoHandler)
+
+	plog.PanicError(router.Run(":8080"))
+}
@@ -43,9 +43,11 @@ type ResponseErrType string
 const (
 	BadRequest          ResponseErrType = "BadRequest"
 	InternalServerError ResponseErrType = "InternalServerError"
+	Unauthorized        ResponseErrType = "Unauthorized"
 	Forbidden           ResponseErrType = "Forbidden"
 	NotFound            ResponseErrType = "NotFound"
 	AlreadyExists       ResponseErrType = "AlreadyExists"
+	PayloadTooLarge     ResponseErrType = "PayloadTooLarge"
 )
 
 type prouterError struct {
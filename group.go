@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/go-puzzles/puzzles/plog"
 	"github.com/gorilla/mux"
@@ -21,6 +22,12 @@ type RouterGroup struct {
 	routes      []iRoute
 	middlewares []Middleware
 	root        bool
+	// notFound handles the paths under the group which match no route
+	notFound HandleFunc
+	// methodNotAllowed replies 405 after the middlewares of the group, see Prouter.serveMethodNotAllowed
+	methodNotAllowed http.HandlerFunc
+	// policy is the path handling of the routes registered on the group
+	policy pathPolicy
 }
 
 func newGroupWithRouter(router *mux.Router) RouterGroup {
@@ -46,18 +53,21 @@ func (rg *RouterGroup) UseMiddleware(m ...Middleware) {
 func (rg *RouterGroup) HandleRouter(routers ...Router) {
 	wrapRoutes := func(routes []Route) {
 		for _, r := range routes {
-			var opt RouteOption
+			var opts []RouteOption
 			switch tr := r.(type) {
+			case *defaultRoute:
+				// the options are applied one by one to record what they match
+				opts = tr.opts
 			case OptRoute:
-				opt = tr.Option
+				opts = []RouteOption{tr.Option}
 			default:
 			}
 
 			rg.initRouter(iRoute{
-				Route:       r,
-				router:      rg.router,
-				middleware:  rg.middlewares,
-				routeOption: opt,
+				Route:        r,
+				router:       rg.router,
+				middleware:   rg.middlewares,
+				routeOptions: opts,
 			})
 		}
 	}
@@ -68,24 +78,10 @@ func (rg *RouterGroup) HandleRouter(routers ...Router) {
 }
 
 func (rg *RouterGroup) handleRoute(method, path string, handler handlerFunc, opts ...RouteOption) {
-	routeOpt := func(r *mux.Route) *mux.Route {
-
-		if opts == nil {
-			return r
-		}
-
-		next := r
-		for _, opt := range opts {
-			next = opt(next)
-		}
-
-		return next
-	}
-
 	r := iRoute{
-		Route:       newHandlerFuncRoute(method, path, handler),
-		router:      rg.router,
-		routeOption: routeOpt,
+		Route:        newHandlerFuncRoute(method, path, handler),
+		router:       rg.router,
+		routeOptions: opts,
 	}
 	r.middleware = rg.middlewares
 
@@ -98,21 +94,93 @@ func (rg *RouterGroup) HandleRoute(method, path string, handler HandleFunc, opts
 }
 
 func (rg *RouterGroup) initRouter(r iRoute) {
-	f := rg.prouter.makeHttpHandler(r)
+	path, params, converters := compileTemplate(r.Path())
+	r.params, r.converters = params, converters
 
-	vr := r.router.Path(r.Path())
+	vr := r.router.Path(path)
 	if r.Method() != "" {
 		vr = vr.Methods(r.Method())
+		rg.prouter.methods[strings.ToUpper(r.Method())] = struct{}{}
 	}
 
-	if r.routeOption != nil {
-		vr = r.routeOption(vr)
+	vr, r.match = applyRouteOptions(vr, r.routeOptions)
+
+	if tpl, err := vr.GetPathTemplate(); err == nil && strings.HasSuffix(tpl, path) {
+		// keep the converter names instead of their patterns
+		r.template = strings.TrimSuffix(tpl, path) + r.Path()
+	} else {
+		r.template = r.Path()
 	}
 
+	f := rg.prouter.makeHttpHandler(r)
 	mr := vr.Handler(f)
+	rg.prouter.addRoute(mr, r, rg)
 	rg.debugPrintRoute(r.Method(), mr, r.Handler())
 }
 
+// methodNotAllowedHandler runs the middlewares of the group before the method not allowed
+// response, so that requests such as CORS preflights pass through them like other requests.
+func (rg *RouterGroup) methodNotAllowedHandler() http.HandlerFunc {
+	return rg.fallbackHandler("MethodNotAllowedHandler", func(ctx *Context) (Response, error) {
+		return rg.prouter.methodNotAllowed(ctx)
+	})
+}
+
+// notFoundHandler runs the middlewares of the group before the not found handler of the group,
+// or the one of the router if the group has none.
+// The trailing slash and case insensitive policies of the routes are applied before.
+func (rg *RouterGroup) notFoundHandler() http.Handler {
+	handler := rg.fallbackHandler("NotFoundHandler", func(ctx *Context) (Response, error) {
+		if rg.notFound != nil {
+			return rg.notFound(ctx)
+		}
+		return rg.prouter.notFound(ctx)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a group with a not found handler serves the method mismatches under its prefix as well
+		if !rg.root && rg.prouter.methodMismatch(r) != nil {
+			rg.prouter.serveMethodNotAllowed(w, r)
+			return
+		}
+		if rg.prouter.serveNotFound(w, r) {
+			return
+		}
+		handler(w, r)
+	})
+}
+
+// fallbackHandler wraps a handler which serves the requests not matching any route with the
+// middlewares of the group.
+func (rg *RouterGroup) fallbackHandler(name string, fn HandleFunc) http.HandlerFunc {
+	var (
+		once    sync.Once
+		handler http.HandlerFunc
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// middlewares can be added after the group is created, build the handler on the first request
+		once.Do(func() {
+			handler = rg.prouter.makeHttpHandler(iRoute{
+				Route:      newHandlerFuncRoute("", "", &wrapHandler{name: name, handler: fn}),
+				router:     rg.router,
+				middleware: rg.middlewares,
+			})
+		})
+		handler(w, r)
+	}
+}
+
+// NotFound sets the handler for the paths under the group which match no route, e.g. a JSON
+// error for /api while the root serves a single page application. Routes registered on the
+// parent after the group with the prefix of the group are shadowed by it.
+func (rg *RouterGroup) NotFound(handler HandleFunc) {
+	rg.notFound = handler
+	rg.router.NotFoundHandler = rg.notFoundHandler()
+	// the group now takes the requests under its prefix from the routes after it
+	rg.prouter.engineState.dirty.Store(true)
+}
+
 func (rg *RouterGroup) debugPrintRoute(method string, route *mux.Route, handler handlerFunc) {
 	if prouterMode != DebugMode {
 		return
@@ -135,12 +203,15 @@ func (rg *RouterGroup) Group(prefix string, middlewares ...HandleFunc) *RouterGr
 		prefix = "/" + prefix
 	}
 
-	router := rg.router.PathPrefix(prefix).Subrouter()
-	g := newGroupWithRouter(router)
+	route := rg.router.PathPrefix(prefix)
+	g := newGroupWithRouter(route.Subrouter())
 	g.middlewares = append(g.middlewares, rg.middlewares...)
 	g.prouter = rg.prouter
+	g.policy = rg.policy
+	g.methodNotAllowed = g.methodNotAllowedHandler()
 
 	g.Use(middlewares...)
+	rg.prouter.groupRoutes[route] = &g
 
 	return &g
 }
@@ -161,7 +232,7 @@ func (rg *RouterGroup) staticHandler(prefix string, fs http.FileSystem) HandleFu
 			r2.URL.Path = p
 			r2.URL.RawPath = rp
 
-			http.FileServer(fs).ServeHTTP(w, r2)
+			http.FileServer(fs).ServeHTTP(&locationWriter{ResponseWriter: w, ctx: ctx}, r2)
 		} else {
 			return nil, MsgError(http.StatusNotFound, fmt.Sprintf("%v static file not found", p))
 		}
@@ -169,6 +240,22 @@ func (rg *RouterGroup) staticHandler(prefix string, fs http.FileSystem) HandleFu
 	}
 }
 
+// locationWriter resolves the relative redirects of http.FileServer against the external base url
+// behind a trusted proxy.
+type locationWriter struct {
+	*ResponseWriter
+	ctx *Context
+}
+
+func (w *locationWriter) WriteHeader(code int) {
+	if code >= http.StatusMultipleChoices && code < http.StatusBadRequest {
+		if location := w.Header().Get("Location"); location != "" {
+			w.Header().Set("Location", w.ctx.redirectLocation(location))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
 func (rg *RouterGroup) Static(path, root string, opts ...RouteOption) {
 	rg.StaticFS(path, http.Dir(root), opts...)
 }
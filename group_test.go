@@ -0,0 +1,158 @@
+package prouter
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	plogslog "github.com/go-puzzles/puzzles/plog/slog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func textHandler(text string) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		return ctx.String(http.StatusOK, text)
+	}
+}
+
+// A method mismatch in a group falls through to the routes registered after the group, like
+// it does with gorilla/mux. Only the root router replies 405.
+func TestGroupMethodMismatchFallsThrough(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+		wantMark string
+	}{
+		{name: "group route", method: http.MethodGet, path: "/api/items", wantCode: 200, wantBody: "api-get"},
+		{name: "root route after the group", method: http.MethodPost, path: "/api/items", wantCode: 200, wantBody: "root-post"},
+		{name: "no route for the method", method: http.MethodDelete, path: "/api/items", wantCode: 405, wantMark: "api"},
+		{name: "root path", method: http.MethodDelete, path: "/other", wantCode: 405, wantMark: "root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			mark := func(name string) HandleFunc {
+				return func(ctx *Context) (Response, error) {
+					ctx.Writer.Header().Add("X-Group", name)
+					return nil, nil
+				}
+			}
+			r.Use(mark("root"))
+			api := r.Group("/api", mark("api"))
+			api.GET("/items", textHandler("api-get"))
+			r.POST("/api/items", textHandler("root-post"))
+			r.GET("/other", textHandler("other"))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+			if tt.wantMark != "" {
+				// the middlewares of the group of the path run before the 405 response
+				if groups := w.Header().Values("X-Group"); groups[len(groups)-1] != tt.wantMark {
+					t.Errorf("middlewares = %v, want the ones of %s", groups, tt.wantMark)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupNotFound(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "group route", method: http.MethodGet, path: "/api/a", wantCode: 200, wantBody: "api-a"},
+		{name: "group not found", method: http.MethodGet, path: "/api/missing", wantCode: 404, wantBody: "api-404"},
+		{name: "root route under the group prefix", method: http.MethodGet, path: "/api/b", wantCode: 404, wantBody: "api-404"},
+		{name: "root not found", method: http.MethodGet, path: "/missing", wantCode: 404},
+		{name: "group method mismatch", method: http.MethodPost, path: "/api/a", wantCode: 405},
+		{name: "group head", method: http.MethodHead, path: "/api/a", wantCode: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			api := r.Group("/api")
+			api.NotFound(func(ctx *Context) (Response, error) {
+				return ctx.String(http.StatusNotFound, "api-404")
+			})
+			api.GET("/a", textHandler("api-a"))
+			r.GET("/api/b", textHandler("root-b"))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+			if tt.wantCode == http.StatusMethodNotAllowed && !strings.Contains(w.Header().Get("Allow"), "GET") {
+				t.Errorf("Allow = %q", w.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+// The not found handler of the router runs through the middlewares of the root group.
+func TestRootNotFoundMiddlewares(t *testing.T) {
+	for _, target := range []string{"/missing", "/api/missing"} {
+		t.Run(target, func(t *testing.T) {
+			logs := new(bytes.Buffer)
+			reg := prometheus.NewRegistry()
+			r := New(WithNotFound(func(ctx *Context) (Response, error) {
+				return ctx.String(http.StatusNotFound, "custom-404 "+ctx.RequestID())
+			}))
+			r.UseMiddleware(
+				NewRequestIDMiddleware(WithRequestIDGenerator(func() string { return "req-1" })),
+				NewLogMiddleware(WithLogger(plogslog.NewSlogJsonLogger(logs)), WithLogFields(LogRequestID)),
+				NewMetricsMiddleware(WithMetricsRegistry(reg)),
+			)
+			r.Group("/api").GET("/a", textHandler("api-a"))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+			if w.Code != http.StatusNotFound || w.Body.String() != "custom-404 req-1" {
+				t.Errorf("status = %d, body = %q, want the not found handler after the request id", w.Code, w.Body)
+			}
+			if got := w.Header().Get(requestIDHeader); got != "req-1" {
+				t.Errorf("%s = %q", requestIDHeader, got)
+			}
+			if !strings.Contains(logs.String(), `"statusCode":"404"`) || !strings.Contains(logs.String(), `"requestId":"req-1"`) {
+				t.Errorf("log = %s", logs)
+			}
+			families, err := reg.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var recorded float64
+			for _, family := range families {
+				if family.GetName() == "prouter_http_requests_total" {
+					for _, metric := range family.GetMetric() {
+						recorded += metric.GetCounter().GetValue()
+					}
+				}
+			}
+			if recorded != 1 {
+				t.Errorf("requests recorded = %v, want 1", recorded)
+			}
+		})
+	}
+}
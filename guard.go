@@ -0,0 +1,115 @@
+package prouter
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// IPAllowlistMiddleware only lets requests from the allowed networks through.
+type IPAllowlistMiddleware struct {
+	networks []*net.IPNet
+}
+
+// NewIPAllowlistMiddleware accepts CIDRs and single IPs, e.g. 10.0.0.0/8 or 127.0.0.1.
+// It panics when an entry can not be parsed.
+func NewIPAllowlistMiddleware(cidrs ...string) *IPAllowlistMiddleware {
+	m := &IPAllowlistMiddleware{}
+	for _, cidr := range cidrs {
+		m.networks = append(m.networks, mustParseNetwork(cidr))
+	}
+	return m
+}
+
+func mustParseNetwork(cidr string) *net.IPNet {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			panic("invalid ip: " + cidr)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+func (m *IPAllowlistMiddleware) allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range m.networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *IPAllowlistMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		if !m.allowed(ctx.ClientIp) {
+			return nil, MsgError(http.StatusForbidden, "forbidden").
+				SetComponent(ErrProuter).
+				SetResponseType(Forbidden)
+		}
+		return handler.Handle(ctx)
+	})
+}
+
+// BasicAuthMiddleware checks the credentials of HTTP basic authentication.
+type BasicAuthMiddleware struct {
+	realm string
+	// accounts holds the sha256 of the passwords, so that the compared values have the same length
+	accounts map[string][sha256.Size]byte
+}
+
+// dummyPasswordHash is compared for an unknown user, so that the response time does not
+// reveal whether the user exists.
+var dummyPasswordHash = sha256.Sum256([]byte("prouter:basic-auth:unknown-user"))
+
+// NewBasicAuthMiddleware takes the accounts as a user to password map.
+func NewBasicAuthMiddleware(realm string, accounts map[string]string) *BasicAuthMiddleware {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+
+	m := &BasicAuthMiddleware{realm: realm, accounts: make(map[string][sha256.Size]byte, len(accounts))}
+	for user, password := range accounts {
+		m.accounts[user] = sha256.Sum256([]byte(password))
+	}
+	return m
+}
+
+func (m *BasicAuthMiddleware) valid(user, password string) bool {
+	expected, ok := m.accounts[user]
+	if !ok {
+		expected = dummyPasswordHash
+	}
+	actual := sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare(actual[:], expected[:]) == 1 && ok
+}
+
+func (m *BasicAuthMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		user, password, ok := ctx.Request.BasicAuth()
+		if !ok || !m.valid(user, password) {
+			ctx.Writer.Header().Set("WWW-Authenticate", `Basic realm="`+strings.ReplaceAll(m.realm, `"`, `\"`)+`"`)
+			return nil, MsgError(http.StatusUnauthorized, "unauthorized").
+				SetComponent(ErrProuter).
+				SetResponseType(Unauthorized)
+		}
+		return handler.Handle(ctx)
+	})
+}
@@ -0,0 +1,129 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	m := NewIPAllowlistMiddleware("10.0.0.0/8", "192.168.1.1", "2001:db8::/32")
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "10.1.2.3", want: true},
+		{ip: "11.0.0.1", want: false},
+		{ip: "192.168.1.1", want: true},
+		{ip: "192.168.1.2", want: false},
+		{ip: "2001:db8::1", want: true},
+		{ip: "2001:db9::1", want: false},
+		{ip: "", want: false},
+		{ip: "not-an-ip", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := m.allowed(tt.ip); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestBasicAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		noAuth   bool
+		wantCode int
+	}{
+		{name: "valid", user: "admin", password: "secret", wantCode: http.StatusNoContent},
+		{name: "wrong password", user: "admin", password: "guess", wantCode: http.StatusUnauthorized},
+		{name: "unknown user", user: "root", password: "secret", wantCode: http.StatusUnauthorized},
+		{name: "empty password", user: "admin", wantCode: http.StatusUnauthorized},
+		{name: "no credentials", noAuth: true, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.UseMiddleware(NewBasicAuthMiddleware("admin", map[string]string{"admin": "secret"}))
+			r.GET("/", func(ctx *Context) (Response, error) { return ctx.NoContent() })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if !tt.noAuth {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != `Basic realm="admin"` {
+				t.Errorf("WWW-Authenticate = %q", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestBasicAuthErrorType(t *testing.T) {
+	m := NewBasicAuthMiddleware("", map[string]string{"admin": "secret"})
+	h := m.WrapHandler(HandleFunc(func(*Context) (Response, error) { return nil, nil }))
+
+	ctx := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	ctx.writer = ResponseWriter{passthroughWriter{httptest.NewRecorder()}, http.StatusOK, noWritten}
+	ctx.Writer = &ctx.writer
+
+	_, err := h.Handle(ctx)
+	rErr := new(prouterError)
+	if !errors.As(err, &rErr) {
+		t.Fatalf("err = %v, want a prouter error", err)
+	}
+	if rErr.Code() != http.StatusUnauthorized || rErr.ResponseErrType() != Unauthorized {
+		t.Errorf("error = %d %s, want 401 %s", rErr.Code(), rErr.ResponseErrType(), Unauthorized)
+	}
+}
+
+func TestEnableProfilingGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		guard      Middleware
+		remoteAddr string
+		wantCode   int
+	}{
+		{name: "nil guard loopback", remoteAddr: "127.0.0.1:1234", wantCode: http.StatusOK},
+		{name: "nil guard ipv6 loopback", remoteAddr: "[::1]:1234", wantCode: http.StatusOK},
+		{name: "nil guard remote", remoteAddr: "203.0.113.7:1234", wantCode: http.StatusForbidden},
+		{
+			name:       "custom guard",
+			guard:      NewIPAllowlistMiddleware("203.0.113.0/24"),
+			remoteAddr: "203.0.113.7:1234",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "custom guard loopback",
+			guard:      NewIPAllowlistMiddleware("203.0.113.0/24"),
+			remoteAddr: "127.0.0.1:1234",
+			wantCode:   http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.EnableProfiling("/debug", tt.guard)
+
+			for _, path := range []string{"/debug/vars", "/debug/runtime"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.RemoteAddr = tt.remoteAddr
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if w.Code != tt.wantCode {
+					t.Errorf("%s: status = %d, want %d", path, w.Code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
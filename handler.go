@@ -10,9 +10,10 @@ package prouter
 
 import (
 	"net/http"
+	"reflect"
 	"strings"
+	"sync"
 
-	"github.com/gin-gonic/gin/binding"
 	"github.com/go-puzzles/puzzles/plog"
 	"github.com/pkg/errors"
 )
@@ -35,6 +36,9 @@ func (h *wrapHandler) Handle(ctx *Context) (Response, error) {
 	return h.handler.Handle(ctx)
 }
 
+// HandleFunc handles a request, the returned Response or error is written in the response envelope.
+// A nil Response with a nil error means the handler has written the response itself, which is what
+// the response helpers of Context such as JSON, File and Stream return.
 type HandleFunc func(ctx *Context) (Response, error)
 
 func (f HandleFunc) WrapHandler(handler handlerFunc) handlerFunc {
@@ -49,10 +53,23 @@ func (f HandleFunc) WrapHandler(handler handlerFunc) handlerFunc {
 }
 
 func (f HandleFunc) Name() string {
-	funcName := plog.GetFuncName(f)
-	fs := strings.Split(funcName, ".")
+	return funcName(f)
+}
+
+// funcNames caches the names of the handler functions by their code pointer.
+var funcNames sync.Map
 
-	return fs[len(fs)-1]
+// funcName returns the short name of fn, the name is resolved by reflection once per function.
+func funcName(fn any) string {
+	pc := reflect.ValueOf(fn).Pointer()
+	if name, ok := funcNames.Load(pc); ok {
+		return name.(string)
+	}
+
+	fs := strings.Split(plog.GetFuncName(fn), ".")
+	name := fs[len(fs)-1]
+	funcNames.Store(pc, name)
+	return name
 }
 
 func (f HandleFunc) Handle(ctx *Context) (Response, error) {
@@ -66,53 +83,13 @@ func BodyParser[RequestT any, ResponseT any](fn func(*Context, *RequestT) (*Resp
 }
 
 func (h bodyParseHandlerFn[RequestT, ResponseT]) Name() string {
-	funcName := plog.GetFuncName(h)
-	fs := strings.Split(funcName, ".")
-
-	return fs[len(fs)-1]
+	return funcName(h)
 }
 
 func (h bodyParseHandlerFn[RequestT, ResponseT]) Handle(ctx *Context) (resp Response, err error) {
 	requestPtr := new(RequestT)
-	r := ctx.Request
-
-	var errMsg string
-	func() {
-		ct := contentType(r)
-		binder := binding.Default(r.Method, ct)
-		if err = binder.Bind(r, requestPtr); err != nil {
-			errMsg = "parse request data failed"
-		}
-		if len(ctx.vars) > 0 {
-			m := make(map[string][]string)
-			for k, v := range ctx.vars {
-				m[k] = []string{v}
-			}
-			if err = binding.Uri.BindUri(m, requestPtr); err != nil {
-				errMsg = "parse request data failed"
-				return
-			}
-		}
-
-		if len(r.Header) > 0 {
-			if err = binding.Header.Bind(r, requestPtr); err != nil {
-				errMsg = "parse request header data failed"
-				return
-			}
-		}
-
-		if len(r.URL.Query()) > 0 {
-			if err = binding.Query.Bind(r, requestPtr); err != nil {
-				errMsg = "parse request query data failed"
-				return
-			}
-		}
-	}()
-
-	if errMsg != "" {
-		return nil, NewErr(http.StatusBadRequest, err, errMsg).
-			SetComponent(ErrProuter).
-			SetResponseType(BadRequest)
+	if err := ctx.Bind(requestPtr); err != nil {
+		return nil, err
 	}
 
 	handleResp, err := h(ctx, requestPtr)
@@ -153,3 +130,11 @@ func filterFlags(content string) string {
 	}
 	return content
 }
+
+// WrapHTTPHandler converts a http.Handler into a HandleFunc, the handler writes the response itself.
+func WrapHTTPHandler(h http.Handler) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		h.ServeHTTP(ctx.Writer, ctx.Request)
+		return nil, nil
+	}
+}
@@ -0,0 +1,254 @@
+package prouter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthCheckTimeout = 3 * time.Second
+
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheckFunc reports whether a dependency is healthy, e.g. a redis ping.
+type HealthCheckFunc func(ctx context.Context) error
+
+// HealthCheck is a named checker. A zero Timeout uses the default timeout of the health options.
+type HealthCheck struct {
+	Name    string
+	Check   HealthCheckFunc
+	Timeout time.Duration
+	// Liveness checks are run by /livez, others are run by /readyz. /healthz runs all of them.
+	Liveness bool
+}
+
+type HealthCheckResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+type healthConfig struct {
+	healthPath string
+	readyPath  string
+	livePath   string
+	timeout    time.Duration
+	cacheTTL   time.Duration
+	checks     []HealthCheck
+}
+
+type HealthOption func(*healthConfig)
+
+// WithHealthPaths changes the paths of the endpoints, an empty path disables the endpoint.
+func WithHealthPaths(health, ready, live string) HealthOption {
+	return func(c *healthConfig) {
+		c.healthPath, c.readyPath, c.livePath = health, ready, live
+	}
+}
+
+// WithHealthCheck adds a readiness check.
+func WithHealthCheck(name string, check HealthCheckFunc) HealthOption {
+	return WithHealthChecks(HealthCheck{Name: name, Check: check})
+}
+
+// WithLivenessCheck adds a liveness check, it should only fail when the process needs a restart.
+func WithLivenessCheck(name string, check HealthCheckFunc) HealthOption {
+	return WithHealthChecks(HealthCheck{Name: name, Check: check, Liveness: true})
+}
+
+func WithHealthChecks(checks ...HealthCheck) HealthOption {
+	return func(c *healthConfig) {
+		c.checks = append(c.checks, checks...)
+	}
+}
+
+// WithHealthTimeout sets the default timeout of every check.
+func WithHealthTimeout(timeout time.Duration) HealthOption {
+	return func(c *healthConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithHealthCacheTTL caches the result of the checks for ttl, so frequent probes do not overload dependencies.
+func WithHealthCacheTTL(ttl time.Duration) HealthOption {
+	return func(c *healthConfig) {
+		c.cacheTTL = ttl
+	}
+}
+
+type cachedHealthResult struct {
+	result  HealthCheckResult
+	expires time.Time
+}
+
+type health struct {
+	conf    *healthConfig
+	router  *Prouter
+	mu      sync.Mutex
+	results map[string]cachedHealthResult
+	// refreshing serializes the refresh of a cached check, so concurrent probes run it once
+	refreshing map[string]*sync.Mutex
+}
+
+func (h *health) cached(name string) (HealthCheckResult, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cached, ok := h.results[name]
+	if !ok || !time.Now().Before(cached.expires) {
+		return HealthCheckResult{}, false
+	}
+	return cached.result, true
+}
+
+func (h *health) run(ctx context.Context, check HealthCheck) HealthCheckResult {
+	if h.conf.cacheTTL <= 0 {
+		return h.check(ctx, check)
+	}
+
+	if result, ok := h.cached(check.Name); ok {
+		return result
+	}
+
+	refresh := h.refreshing[check.Name]
+	refresh.Lock()
+	defer refresh.Unlock()
+	// another probe may have refreshed the result while waiting for the lock
+	if result, ok := h.cached(check.Name); ok {
+		return result
+	}
+
+	result := h.check(ctx, check)
+	h.mu.Lock()
+	h.results[check.Name] = cachedHealthResult{result: result, expires: time.Now().Add(h.conf.cacheTTL)}
+	h.mu.Unlock()
+	return result
+}
+
+func (h *health) check(ctx context.Context, check HealthCheck) HealthCheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = h.conf.timeout
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("check panic: %v", r)
+			}
+		}()
+		errCh <- check.Check(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-checkCtx.Done():
+		err = checkCtx.Err()
+	}
+
+	result := HealthCheckResult{Status: HealthStatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (h *health) report(ctx context.Context, match func(HealthCheck) bool) *HealthReport {
+	report := &HealthReport{Status: HealthStatusOK, Checks: make(map[string]HealthCheckResult)}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range h.conf.checks {
+		if !match(check) {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != HealthStatusOK {
+				report.Status = HealthStatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (h *health) handler(readiness bool, match func(HealthCheck) bool) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		// a timed out check outlives the handler, it must not hold the pooled context
+		report := h.report(ctx.Ctx(), match)
+		if readiness && h.router.ShuttingDown() {
+			report.Status = HealthStatusFail
+			report.Checks["shutdown"] = HealthCheckResult{
+				Status: HealthStatusFail,
+				Error:  "server is shutting down",
+			}
+		}
+
+		if report.Status != HealthStatusOK {
+			return ErrorResponse(http.StatusServiceUnavailable, report.Status).SetData(report), nil
+		}
+		return SuccessResponse(report), nil
+	}
+}
+
+// EnableHealth registers /healthz, /readyz and /livez. Readiness fails automatically
+// once Shutdown has been called so that load balancers stop sending new requests.
+func (v *Prouter) EnableHealth(opts ...HealthOption) {
+	conf := &healthConfig{
+		healthPath: "/healthz",
+		readyPath:  "/readyz",
+		livePath:   "/livez",
+		timeout:    defaultHealthCheckTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	h := &health{
+		conf:       conf,
+		router:     v,
+		results:    make(map[string]cachedHealthResult),
+		refreshing: make(map[string]*sync.Mutex),
+	}
+	for _, check := range conf.checks {
+		h.refreshing[check.Name] = new(sync.Mutex)
+	}
+
+	register := func(path, name string, readiness bool, match func(HealthCheck) bool) {
+		if path == "" {
+			return
+		}
+		v.handleRoute(http.MethodGet, path, &wrapHandler{name: name, handler: h.handler(readiness, match)})
+	}
+
+	register(conf.healthPath, "HealthHandler", true, func(HealthCheck) bool { return true })
+	register(conf.readyPath, "ReadinessHandler", true, func(c HealthCheck) bool { return !c.Liveness })
+	register(conf.livePath, "LivenessHandler", false, func(c HealthCheck) bool { return c.Liveness })
+}
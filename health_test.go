@@ -0,0 +1,163 @@
+package prouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	failing := errors.New("down")
+	tests := []struct {
+		name         string
+		opts         []HealthOption
+		shuttingDown bool
+		path         string
+		wantCode     int
+		wantChecks   []string
+	}{
+		{
+			name:       "all healthy",
+			opts:       []HealthOption{WithHealthCheck("db", func(context.Context) error { return nil })},
+			path:       "/healthz",
+			wantCode:   http.StatusOK,
+			wantChecks: []string{"db"},
+		},
+		{
+			name:       "readiness fails",
+			opts:       []HealthOption{WithHealthCheck("db", func(context.Context) error { return failing })},
+			path:       "/readyz",
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: []string{"db"},
+		},
+		{
+			name:     "liveness ignores readiness checks",
+			opts:     []HealthOption{WithHealthCheck("db", func(context.Context) error { return failing })},
+			path:     "/livez",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "liveness fails",
+			opts:       []HealthOption{WithLivenessCheck("deadlock", func(context.Context) error { return failing })},
+			path:       "/livez",
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: []string{"deadlock"},
+		},
+		{
+			name: "timeout",
+			opts: []HealthOption{
+				WithHealthTimeout(10 * time.Millisecond),
+				WithHealthCheck("slow", func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				}),
+			},
+			path:       "/readyz",
+			wantCode:   http.StatusServiceUnavailable,
+			wantChecks: []string{"slow"},
+		},
+		{
+			name:         "shutting down",
+			shuttingDown: true,
+			path:         "/readyz",
+			wantCode:     http.StatusServiceUnavailable,
+			wantChecks:   []string{"shutdown"},
+		},
+		{
+			name:         "liveness while shutting down",
+			shuttingDown: true,
+			path:         "/livez",
+			wantCode:     http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.EnableHealth(tt.opts...)
+			if tt.shuttingDown {
+				_ = r.Shutdown(context.Background())
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			var body struct {
+				Data HealthReport `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Data.Checks) != len(tt.wantChecks) {
+				t.Fatalf("checks = %v, want %v", body.Data.Checks, tt.wantChecks)
+			}
+			for _, name := range tt.wantChecks {
+				if _, ok := body.Data.Checks[name]; !ok {
+					t.Errorf("check %s is missing from %v", name, body.Data.Checks)
+				}
+			}
+		})
+	}
+}
+
+// Concurrent probes on an expired cache run the check once.
+func TestHealthCacheRefreshOnce(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	r := New()
+	r.EnableHealth(
+		WithHealthCacheTTL(time.Minute),
+		WithHealthCheck("db", func(context.Context) error {
+			calls.Add(1)
+			<-release
+			return nil
+		}),
+	)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("check ran %d times, want 1", n)
+	}
+}
+
+func TestRunShutdown(t *testing.T) {
+	r := New()
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Run("127.0.0.1:0") }()
+
+	// Shutdown may run before or after Run has stored the server
+	deadline := time.Now().Add(time.Second)
+	for r.server.Load() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Run() = %v, want http.ErrServerClosed", err)
+	}
+	if !r.ShuttingDown() {
+		t.Error("ShuttingDown() = false")
+	}
+}
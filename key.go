@@ -0,0 +1,50 @@
+package prouter
+
+import (
+	"context"
+	"fmt"
+)
+
+// Key is a typed context key. Values set with it on the Context of a request, e.g. by a middleware,
+// can be read from the context.Context passed down to other services as well.
+//
+//	var CurrentUser = prouter.NewKey[*User]("currentUser")
+//
+//	CurrentUser.Set(ctx, user)
+//	user, ok := CurrentUser.Get(ctx)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a key, keys are compared by identity so two keys with the same name do not collide.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Set stores v in the Context of the request.
+func (k *Key[T]) Set(ctx *Context, v T) {
+	ctx.WithValue(k, v)
+}
+
+// WithValue returns a copy of ctx carrying v, for contexts which are not a Context.
+func (k *Key[T]) WithValue(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+func (k *Key[T]) Get(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// MustGet panics when ctx carries no value for the key.
+func (k *Key[T]) MustGet(ctx context.Context) T {
+	v, ok := k.Get(ctx)
+	if !ok {
+		panic(fmt.Sprintf("prouter: context key %s not set", k.name))
+	}
+	return v
+}
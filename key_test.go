@@ -0,0 +1,88 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type keyUser struct {
+	name string
+}
+
+// lookupUser stands for a service which only receives a context.Context.
+func lookupUser(ctx context.Context, key *Key[*keyUser]) string {
+	if user, ok := key.Get(ctx); ok {
+		return user.name
+	}
+	return "anonymous"
+}
+
+func TestKeySetGet(t *testing.T) {
+	currentUser := NewKey[*keyUser]("user")
+	// a key with the same name and type is another key
+	otherUser := NewKey[*keyUser]("user")
+	requestCount := NewKey[int]("user")
+
+	r := New()
+	r.Use(func(ctx *Context) (Response, error) {
+		if ctx.Request.Header.Get("X-User") != "" {
+			currentUser.Set(ctx, &keyUser{name: ctx.Request.Header.Get("X-User")})
+		}
+		requestCount.Set(ctx, 7)
+		return nil, nil
+	})
+	r.GET("/me", func(ctx *Context) (Response, error) {
+		if _, ok := otherUser.Get(ctx); ok {
+			t.Error("a key reads the value of another key with the same name")
+		}
+		if n, ok := requestCount.Get(ctx); !ok || n != 7 {
+			t.Errorf("requestCount = %d %v, want 7", n, ok)
+		}
+		return ctx.String(http.StatusOK, lookupUser(ctx, currentUser))
+	})
+
+	for header, want := range map[string]string{"gopher": "gopher", "": "anonymous"} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if header != "" {
+			req.Header.Set("X-User", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Errorf("X-User %q: body = %q, want %q", header, w.Body, want)
+		}
+	}
+}
+
+func TestKeyWithValue(t *testing.T) {
+	limit := NewKey[int]("limit")
+	name := NewKey[string]("limit")
+
+	ctx := limit.WithValue(context.Background(), 10)
+	ctx = name.WithValue(ctx, "ten")
+
+	if v, ok := limit.Get(ctx); !ok || v != 10 {
+		t.Errorf("limit = %d %v, want 10", v, ok)
+	}
+	if v := name.MustGet(ctx); v != "ten" {
+		t.Errorf("name = %q, want ten", v)
+	}
+	if v, ok := limit.Get(context.Background()); ok || v != 0 {
+		t.Errorf("limit of an empty context = %d %v, want the zero value", v, ok)
+	}
+}
+
+func TestKeyMustGetPanics(t *testing.T) {
+	key := NewKey[string]("tenant")
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "tenant") {
+			t.Errorf("recover() = %q, want a panic naming the key", msg)
+		}
+	}()
+	key.MustGet(context.Background())
+	t.Error("MustGet of a missing key returned")
+}
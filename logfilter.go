@@ -0,0 +1,106 @@
+package prouter
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"time"
+)
+
+// LogSkipper reports whether the request should not be logged.
+type LogSkipper func(ctx *Context) bool
+
+type logFilter struct {
+	skipPatterns []string
+	skippers     []LogSkipper
+	// sampleRate is the fraction of successful requests which are logged, 1 logs all of them
+	sampleRate float64
+	// random returns a number in [0, 1) to sample the requests, it is replaced by the tests
+	random func() float64
+
+	slowThreshold time.Duration
+	// routeSlowThresholds overrides slowThreshold for a route template
+	routeSlowThresholds map[string]time.Duration
+}
+
+func newLogFilter() *logFilter {
+	return &logFilter{
+		sampleRate:          1,
+		random:              rand.Float64,
+		routeSlowThresholds: make(map[string]time.Duration),
+	}
+}
+
+// WithLogSkipPaths excludes requests whose path or route template matches one of the
+// patterns. Patterns use the path.Match syntax, e.g. /healthz or /static/*.
+func WithLogSkipPaths(patterns ...string) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.filter.skipPatterns = append(lm.filter.skipPatterns, patterns...)
+	}
+}
+
+// WithLogSkipper excludes requests for which fn returns true.
+func WithLogSkipper(fn LogSkipper) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.filter.skippers = append(lm.filter.skippers, fn)
+	}
+}
+
+// WithLogSampling logs only the given fraction of successful requests, rate is between 0 and 1.
+// Failed and slow requests are always logged.
+func WithLogSampling(rate float64) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.filter.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithLogSlowThreshold logs requests which take longer than d with the warning level
+// regardless of their status, slow requests bypass sampling but not path exclusion.
+func WithLogSlowThreshold(d time.Duration) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.filter.slowThreshold = d
+	}
+}
+
+// WithLogRouteSlowThreshold overrides the slow threshold for a route template, e.g. /upload/{id}.
+func WithLogRouteSlowThreshold(route string, d time.Duration) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.filter.routeSlowThresholds[route] = d
+	}
+}
+
+func (f *logFilter) skip(ctx *Context) bool {
+	for _, pattern := range f.skipPatterns {
+		if ok, _ := path.Match(pattern, ctx.Request.URL.Path); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, ctx.RouteTemplate()); ok {
+			return true
+		}
+	}
+
+	for _, skipper := range f.skippers {
+		if skipper(ctx) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *logFilter) isSlow(ctx *Context, spendTime time.Duration) bool {
+	threshold, ok := f.routeSlowThresholds[ctx.RouteTemplate()]
+	if !ok {
+		threshold = f.slowThreshold
+	}
+
+	return threshold > 0 && spendTime >= threshold
+}
+
+func (f *logFilter) sampled(statusCode int, err error) bool {
+	if err != nil || statusCode >= http.StatusBadRequest || f.sampleRate >= 1 {
+		return true
+	}
+
+	return f.random() < f.sampleRate
+}
@@ -0,0 +1,157 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withLogRandom makes the sampling of LogMiddleware draw n.
+func withLogRandom(n float64) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.filter.random = func() float64 { return n }
+	}
+}
+
+func TestLogFilter(t *testing.T) {
+	setup := func(r *Prouter) {
+		r.GET("/users/{id}", getUser)
+		r.GET("/fail", func(ctx *Context) (Response, error) {
+			return nil, errors.New("boom")
+		})
+		r.GET("/missing", func(ctx *Context) (Response, error) {
+			return ctx.String(http.StatusNotFound, "missing")
+		})
+		r.GET("/healthz", textHandler("ok"))
+		r.GET("/static/{file:.*}", varHandler("file"))
+	}
+
+	tests := []struct {
+		name     string
+		opts     []LogOption
+		target   string
+		header   http.Header
+		wantLog  bool
+		wantSlow bool
+	}{
+		{
+			name:    "no filter",
+			target:  "/users/1",
+			wantLog: true,
+		},
+		{
+			name:    "sampled in",
+			opts:    []LogOption{WithLogSampling(0.5), withLogRandom(0.49)},
+			target:  "/users/1",
+			wantLog: true,
+		},
+		{
+			name:   "sampled out",
+			opts:   []LogOption{WithLogSampling(0.5), withLogRandom(0.5)},
+			target: "/users/1",
+		},
+		{
+			name:   "rate clamped to 0",
+			opts:   []LogOption{WithLogSampling(-1), withLogRandom(0)},
+			target: "/users/1",
+		},
+		{
+			name:    "error always logged",
+			opts:    []LogOption{WithLogSampling(0), withLogRandom(0.99)},
+			target:  "/fail",
+			wantLog: true,
+		},
+		{
+			name:    "client error always logged",
+			opts:    []LogOption{WithLogSampling(0), withLogRandom(0.99)},
+			target:  "/missing",
+			wantLog: true,
+		},
+		{
+			name:     "slow always logged",
+			opts:     []LogOption{WithLogSampling(0), withLogRandom(0.99), WithLogSlowThreshold(time.Nanosecond)},
+			target:   "/users/1",
+			wantLog:  true,
+			wantSlow: true,
+		},
+		{
+			name: "route slow threshold",
+			opts: []LogOption{
+				WithLogSampling(0), withLogRandom(0.99),
+				WithLogSlowThreshold(time.Nanosecond), WithLogRouteSlowThreshold("/users/{id}", time.Hour),
+			},
+			target: "/users/1",
+		},
+		{
+			name:     "route slow threshold of another route",
+			opts:     []LogOption{WithLogSampling(0), withLogRandom(0.99), WithLogRouteSlowThreshold("/healthz", time.Nanosecond)},
+			target:   "/healthz",
+			wantLog:  true,
+			wantSlow: true,
+		},
+		{
+			name:   "skip path",
+			opts:   []LogOption{WithLogSkipPaths("/healthz")},
+			target: "/healthz",
+		},
+		{
+			name:   "skip path pattern",
+			opts:   []LogOption{WithLogSkipPaths("/static/*")},
+			target: "/static/app.js",
+		},
+		{
+			name:    "skip another path",
+			opts:    []LogOption{WithLogSkipPaths("/users/2", "/healthz")},
+			target:  "/users/1",
+			wantLog: true,
+		},
+		{
+			name:   "skip route template",
+			opts:   []LogOption{WithLogSkipPaths("/users/{id}")},
+			target: "/users/1",
+		},
+		{
+			name:   "skip before slow and errors",
+			opts:   []LogOption{WithLogSkipPaths("/fail"), WithLogSlowThreshold(time.Nanosecond)},
+			target: "/fail",
+		},
+		{
+			name: "skipper",
+			opts: []LogOption{WithLogSkipper(func(ctx *Context) bool {
+				return ctx.Request.Header.Get("X-Probe") != ""
+			})},
+			target: "/users/1",
+			header: http.Header{"X-Probe": {"1"}},
+		},
+		{
+			name: "skipper not matching",
+			opts: []LogOption{WithLogSkipper(func(ctx *Context) bool {
+				return ctx.Request.Header.Get("X-Probe") != ""
+			})},
+			target:  "/users/1",
+			wantLog: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+
+			records := logRecords(t, setup, req, tt.opts...)
+			if (len(records) == 1) != tt.wantLog || len(records) > 1 {
+				t.Fatalf("records = %v, want logged %v", records, tt.wantLog)
+			}
+			if !tt.wantLog {
+				return
+			}
+			if _, slow := records[0]["slow"]; slow != tt.wantSlow {
+				t.Errorf("slow = %v, want %v", records[0]["slow"], tt.wantSlow)
+			}
+		})
+	}
+}
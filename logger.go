@@ -2,7 +2,7 @@ package prouter
 
 import (
 	"context"
-	"net"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -23,8 +23,39 @@ const (
 	logMsg = "statusCode=%v duration=%v clientIp=%s method=%s path=%s"
 )
 
+// LogField is an optional field recorded by LogMiddleware, fields can be combined with |.
+type LogField uint
+
+const (
+	LogRequestSize LogField = 1 << iota
+	LogResponseSize
+	LogUserAgent
+	LogReferer
+	LogRoute
+	LogHandler
+	LogRequestID
+	LogSessionID
+	LogHeaders
+
+	DefaultLogFields = LogResponseSize
+	AllLogFields     = LogRequestSize | LogResponseSize | LogUserAgent | LogReferer | LogRoute |
+		LogHandler | LogRequestID | LogSessionID | LogHeaders
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// sensitiveHeaders are left out of the header fields of the logs and masked by BodyLogMiddleware.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
 type LogMiddleware struct {
 	logger plog.Logger
+	format LogFormat
+	output io.Writer
+	fields LogField
+	// headerAllowlist contains the headers logged when LogHeaders is enabled, empty means all
+	headerAllowlist []string
+	headerDenylist  map[string]struct{}
+	filter          *logFilter
 }
 
 type LogOption func(*LogMiddleware)
@@ -35,45 +66,166 @@ func WithLogger(l plog.Logger) LogOption {
 	}
 }
 
+// WithLogFields replaces the optional fields which will be logged.
+func WithLogFields(fields ...LogField) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.fields = 0
+		for _, f := range fields {
+			lm.fields |= f
+		}
+	}
+}
+
+// WithLogHeaders enables header logging and only logs the given request headers.
+func WithLogHeaders(headers ...string) LogOption {
+	return func(lm *LogMiddleware) {
+		lm.fields |= LogHeaders
+		for _, h := range headers {
+			lm.headerAllowlist = append(lm.headerAllowlist, http.CanonicalHeaderKey(h))
+		}
+	}
+}
+
+// WithLogHeaderDenylist adds headers which will never be logged.
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie are denied by default.
+func WithLogHeaderDenylist(headers ...string) LogOption {
+	return func(lm *LogMiddleware) {
+		for _, h := range headers {
+			lm.headerDenylist[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+	}
+}
+
 func NewLogMiddleware(opts ...LogOption) *LogMiddleware {
 	lm := &LogMiddleware{
-		logger: plog.GetLogger(),
+		logger:         plog.GetLogger(),
+		fields:         DefaultLogFields,
+		headerDenylist: make(map[string]struct{}),
+		filter:         newLogFilter(),
+	}
+	for _, h := range sensitiveHeaders {
+		lm.headerDenylist[h] = struct{}{}
 	}
 
 	for _, opt := range opts {
 		opt(lm)
 	}
 
+	if lm.format != LogFormatPlog && lm.output == nil {
+		lm.output = newStdoutWriter()
+	}
+
 	return lm
 }
 
 func (lm *LogMiddleware) RemoteIP(r *http.Request) string {
-	ip, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
-	if err != nil {
-		return ""
-	}
-	return ip
+	return remoteIP(r)
 }
 
+// ClientIP returns the ip of the peer of the request. Context.ClientIp is the client ip
+// resolved by the router, which honours the trusted proxies.
 func (lm *LogMiddleware) ClientIP(r *http.Request) string {
-	remoteIP := net.ParseIP(lm.RemoteIP(r))
-	if remoteIP == nil {
-		return ""
+	return clientIP(r)
+}
+
+func (lm *LogMiddleware) has(f LogField) bool {
+	return lm.fields&f != 0
+}
+
+func (lm *LogMiddleware) headerArgs(header http.Header) []any {
+	var args []any
+
+	logHeader := func(key string, values []string) {
+		if _, denied := lm.headerDenylist[key]; denied || len(values) == 0 {
+			return
+		}
+		args = append(args, "header."+key, strings.Join(values, ","))
 	}
 
-	return remoteIP.String()
+	if len(lm.headerAllowlist) > 0 {
+		for _, key := range lm.headerAllowlist {
+			logHeader(key, header.Values(key))
+		}
+		return args
+	}
+
+	for key, values := range header {
+		logHeader(http.CanonicalHeaderKey(key), values)
+	}
+	return args
 }
 
-func (lm *LogMiddleware) log(ctx *Context, resp Response, err error) {
+func (lm *LogMiddleware) fieldArgs(ctx *Context, body *countingBody) []any {
+	var args []any
+	r := ctx.Request
+
+	if lm.has(LogRequestSize) {
+		size := r.ContentLength
+		if body != nil && body.n > 0 {
+			size = body.n
+		}
+		if size < 0 {
+			size = 0
+		}
+		args = append(args, "requestSize", size)
+	}
+	if lm.has(LogResponseSize) {
+		args = append(args, "responseSize", ctx.Writer.Size())
+	}
+	if lm.has(LogUserAgent) {
+		args = append(args, "userAgent", r.UserAgent())
+	}
+	if lm.has(LogReferer) {
+		args = append(args, "referer", r.Referer())
+	}
+	if lm.has(LogRoute) {
+		args = append(args, "route", ctx.RouteTemplate())
+	}
+	if lm.has(LogHandler) {
+		args = append(args, "handlerName", ctx.HandlerName())
+	}
+	if lm.has(LogRequestID) {
+		id := ctx.RequestID()
+		if id == "" {
+			id = ctx.Writer.Header().Get(requestIDHeader)
+		}
+		if id == "" {
+			id = r.Header.Get(requestIDHeader)
+		}
+		args = append(args, "requestId", id)
+	}
+	if lm.has(LogSessionID) {
+		args = append(args, "sessionId", ctx.session.ID())
+	}
+	if lm.has(LogHeaders) {
+		args = append(args, lm.headerArgs(r.Header)...)
+	}
+
+	return args
+}
+
+func (lm *LogMiddleware) log(ctx *Context, resp Response, err error, body *countingBody) {
 	spendTime := time.Since(ctx.startTime)
 
 	statusCode := ctx.Writer.StatusCode()
-	if err != nil && statusCode == http.StatusOK {
+	if err != nil && statusCode == http.StatusOK && !ctx.Writer.Written() {
 		statusCode = http.StatusInternalServerError
 	}
 
+	slow := lm.filter.isSlow(ctx, spendTime)
+	if !slow && !lm.filter.sampled(statusCode, err) {
+		return
+	}
+
+	if lm.format != LogFormatPlog {
+		lm.writeAccess(ctx, resp, err, body, statusCode, spendTime, slow)
+		return
+	}
+
 	var logFunc func(ctx context.Context, msg string, v ...any)
 	switch {
+	case slow:
+		logFunc = lm.logger.Warnc
 	case statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices:
 		logFunc = lm.logger.Infoc
 	case statusCode >= http.StatusMultipleChoices && statusCode < http.StatusBadRequest:
@@ -91,6 +243,10 @@ func (lm *LogMiddleware) log(ctx *Context, resp Response, err error) {
 		"clientIp", ctx.ClientIp,
 		"method", ctx.Method,
 	}
+	args = append(args, lm.fieldArgs(ctx, body)...)
+	if slow {
+		args = append(args, "slow", true)
+	}
 
 	if err != nil {
 		args = append(args, "err", err)
@@ -102,18 +258,64 @@ func (lm *LogMiddleware) log(ctx *Context, resp Response, err error) {
 	logFunc(ctx, "handle path: %v.", args...)
 }
 
+func (lm *LogMiddleware) writeAccess(ctx *Context, resp Response, err error, body *countingBody, statusCode int, spendTime time.Duration, slow bool) {
+	level := levelName(statusCode)
+	if slow {
+		level = "warn"
+	}
+
+	record := newAccessRecord(ctx, statusCode, spendTime, level)
+	if lm.format == LogFormatJSON || lm.format == LogFormatLogfmt {
+		record.fields = lm.fieldArgs(ctx, body)
+		if slow {
+			record.fields = append(record.fields, "slow", true)
+		}
+		record.err = err
+		if err != nil && resp != nil {
+			record.errMsg = resp.GetMessage()
+		}
+	}
+
+	if _, wErr := lm.output.Write(record.render(lm.format)); wErr != nil {
+		plog.Errorc(ctx, "write access log error: %v", wErr)
+	}
+}
+
 func (lm *LogMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 	return HandleFunc(func(ctx *Context) (Response, error) {
+		if lm.filter.skip(ctx) {
+			return handler.Handle(ctx)
+		}
+
 		var (
 			resp Response
 			err  error
+			body *countingBody
 		)
-		defer func() {
-			lm.log(ctx, resp, err)
-		}()
+		if lm.has(LogRequestSize) && ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+			body = &countingBody{ReadCloser: ctx.Request.Body}
+			ctx.Request.Body = body
+		}
+
+		// log after the response is written so that the status and size are final
+		ctx.onFinish(func() {
+			lm.log(ctx, resp, err, body)
+		})
 
 		resp, err = handler.Handle(ctx)
 
 		return resp, err
 	})
 }
+
+// countingBody counts the bytes read from the request body.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,190 @@
+package prouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	plogslog "github.com/go-puzzles/puzzles/plog/slog"
+	"github.com/gorilla/sessions"
+)
+
+// fixedSessionStore returns sessions with a known id.
+type fixedSessionStore struct{ id string }
+
+func (s fixedSessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return s.New(r, name)
+}
+
+func (s fixedSessionStore) New(_ *http.Request, name string) (*sessions.Session, error) {
+	sess := sessions.NewSession(s, name)
+	sess.ID = s.id
+	return sess, nil
+}
+
+func (s fixedSessionStore) Save(*http.Request, http.ResponseWriter, *sessions.Session) error {
+	return nil
+}
+
+// logRecords serves req with LogMiddleware writing json records into a buffer and returns them.
+func logRecords(t *testing.T, setup func(r *Prouter), req *http.Request, opts ...LogOption) []map[string]any {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	r := New()
+	r.UseMiddleware(NewLogMiddleware(append([]LogOption{WithLogger(plogslog.NewSlogJsonLogger(buf))}, opts...)...))
+	setup(r)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := make(map[string]any)
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogHeaders(t *testing.T) {
+	header := http.Header{
+		"Authorization":       {"Bearer secret"},
+		"Proxy-Authorization": {"Basic secret"},
+		"Cookie":              {"session=secret"},
+		"X-Api-Key":           {"secret"},
+		"X-Trace":             {"t1"},
+		"Accept":              {"text/plain"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     []LogOption
+		want     map[string]string
+		wantNone bool
+	}{
+		{
+			name:     "headers not logged by default",
+			wantNone: true,
+		},
+		{
+			name: "all headers but the sensitive ones",
+			opts: []LogOption{WithLogFields(LogHeaders)},
+			want: map[string]string{"header.X-Trace": "t1", "header.Accept": "text/plain", "header.X-Api-Key": "secret"},
+		},
+		{
+			name: "denylist",
+			opts: []LogOption{WithLogFields(LogHeaders), WithLogHeaderDenylist("x-api-key")},
+			want: map[string]string{"header.X-Trace": "t1", "header.Accept": "text/plain"},
+		},
+		{
+			name: "allowlist keeps the denylist",
+			opts: []LogOption{WithLogFields(), WithLogHeaders("x-trace", "authorization", "cookie")},
+			want: map[string]string{"header.X-Trace": "t1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/items", nil)
+			for key, values := range header {
+				req.Header[key] = values
+			}
+			records := logRecords(t, func(r *Prouter) { r.GET("/items", textHandler("ok")) }, req, tt.opts...)
+			if len(records) != 1 {
+				t.Fatalf("records = %v", records)
+			}
+
+			got := make(map[string]string)
+			for key, val := range records[0] {
+				if strings.HasPrefix(key, "header.") {
+					got[key] = val.(string)
+				}
+				if s, ok := val.(string); ok && strings.Contains(s, "secret") && tt.want[key] != s {
+					t.Errorf("%s = %q leaks a secret", key, s)
+				}
+			}
+			if tt.wantNone && len(got) > 0 {
+				t.Errorf("headers = %v, want none", got)
+			}
+			if !tt.wantNone && !maps.Equal(got, tt.want) {
+				t.Errorf("headers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func getUser(ctx *Context) (Response, error) {
+	return ctx.String(http.StatusOK, "user")
+}
+
+func TestLogFields(t *testing.T) {
+	setup := func(r *Prouter) {
+		r.UseMiddleware(NewRequestIDMiddleware(WithRequestIDGenerator(func() string { return "req-1" })))
+		r.UseMiddleware(NewSessionMiddleware("sess", fixedSessionStore{id: "sess-1"}))
+		r.GET("/users/{id}", getUser)
+	}
+
+	tests := []struct {
+		name    string
+		fields  []LogField
+		want    map[string]string
+		missing []string
+	}{
+		{
+			name:    "default fields",
+			want:    map[string]string{"responseSize": "4", "statusCode": "200", "method": "GET"},
+			missing: []string{"route", "handlerName", "sessionId", "userAgent"},
+		},
+		{
+			name:   "route and handler",
+			fields: []LogField{LogRoute, LogHandler},
+			want:   map[string]string{"route": "/users/{id}", "handlerName": "getUser"},
+		},
+		{
+			name:    "request and session ids",
+			fields:  []LogField{LogRequestID, LogSessionID},
+			want:    map[string]string{"requestId": "req-1", "sessionId": "sess-1"},
+			missing: []string{"route", "responseSize"},
+		},
+		{
+			name:   "request",
+			fields: []LogField{LogUserAgent, LogReferer, LogRequestSize},
+			want:   map[string]string{"userAgent": "test-agent", "referer": "https://example.com/", "requestSize": "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []LogOption
+			if tt.fields != nil {
+				opts = append(opts, WithLogFields(tt.fields...))
+			}
+			req := httptest.NewRequest(http.MethodGet, "/users/7", strings.NewReader("hello"))
+			req.Header.Set("User-Agent", "test-agent")
+			req.Header.Set("Referer", "https://example.com/")
+
+			records := logRecords(t, setup, req, opts...)
+			if len(records) != 1 {
+				t.Fatalf("records = %v", records)
+			}
+			for key, want := range tt.want {
+				if got, _ := records[0][key].(string); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+			for _, key := range tt.missing {
+				if _, ok := records[0][key]; ok {
+					t.Errorf("%s is logged", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package prouter
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// matchMethod matches r as if it was sent with method, it returns the handler and vars of the route.
+func (v *Prouter) matchMethod(r *http.Request, method string) (http.Handler, map[string]string) {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Method = method
+
+	var match mux.RouteMatch
+	if !v.router.Match(r2, &match) || match.MatchErr != nil || match.Route == nil {
+		return nil, nil
+	}
+	return match.Handler, match.Vars
+}
+
+// allowedMethods returns the methods registered for the path of r, sorted for the Allow header.
+func (v *Prouter) allowedMethods(r *http.Request) []string {
+	var allowed []string
+	for method := range v.methods {
+		if h, _ := v.matchMethod(r, method); h != nil {
+			allowed = append(allowed, method)
+		}
+	}
+
+	if slices.Contains(allowed, http.MethodGet) && !slices.Contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
+	}
+
+	slices.Sort(allowed)
+	return allowed
+}
+
+// serveHead serves a HEAD request with the GET route of its path, the body is discarded.
+func (v *Prouter) serveHead(w http.ResponseWriter, r *http.Request) bool {
+	h, vars := v.matchMethod(r, http.MethodGet)
+	if h == nil {
+		return false
+	}
+
+	h.ServeHTTP(&headResponseWriter{passthroughWriter{w}}, mux.SetURLVars(r, vars))
+	return true
+}
+
+// methodMismatch returns the first route, in the order of the router, whose path matches r
+// but whose method does not.
+func (v *Prouter) methodMismatch(r *http.Request) *routeEntry {
+	var entry *routeEntry
+	_ = v.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		e, ok := v.routeIndex[route]
+		if !ok {
+			return nil
+		}
+		var match mux.RouteMatch
+		if route.Match(r, &match) || match.MatchErr != mux.ErrMethodMismatch {
+			return nil
+		}
+		entry = e
+		return errWalkDone
+	})
+	return entry
+}
+
+// serveMethodNotAllowed serves the requests whose path is registered with other methods. It runs
+// the middlewares of the group of the first route matching the path. A HEAD request is served by
+// the GET route of the path if there is no HEAD route.
+func (v *Prouter) serveMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead && v.serveHead(w, r) {
+		return
+	}
+
+	group := &v.RouterGroup
+	if entry := v.methodMismatch(r); entry != nil {
+		group = entry.group
+	}
+	group.methodNotAllowed(w, r)
+}
+
+// methodNotAllowed replies 405 with the Allow header, OPTIONS requests are answered with the
+// allowed methods instead.
+func methodNotAllowed(ctx *Context) (Response, error) {
+	allowed := ctx.router.allowedMethods(ctx.Request)
+	ctx.Writer.Header().Set("Allow", strings.Join(allowed, ", "))
+
+	if ctx.Method == http.MethodOptions {
+		return SuccessResponse(allowed), nil
+	}
+
+	return nil, MsgError(http.StatusMethodNotAllowed, "method not allowed").SetComponent(ErrProuter)
+}
+
+func notFound(*Context) (Response, error) {
+	return nil, MsgError(http.StatusNotFound, "page not found").SetComponent(ErrProuter)
+}
+
+var (
+	_ http.Flusher    = (*headResponseWriter)(nil)
+	_ http.Hijacker   = (*headResponseWriter)(nil)
+	_ http.Pusher     = (*headResponseWriter)(nil)
+	_ io.StringWriter = (*headResponseWriter)(nil)
+)
+
+// headResponseWriter discards the body written by a GET handler serving a HEAD request.
+type headResponseWriter struct {
+	passthroughWriter
+}
+
+func (w *headResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *headResponseWriter) WriteString(s string) (int, error) {
+	return len(s), nil
+}
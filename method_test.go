@@ -0,0 +1,96 @@
+package prouter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantCode  int
+		wantAllow string
+		wantBody  string
+	}{
+		{name: "registered method", method: http.MethodGet, path: "/items", wantCode: 200, wantBody: "list"},
+		{name: "405", method: http.MethodDelete, path: "/items", wantCode: 405, wantAllow: "GET, HEAD, OPTIONS, POST"},
+		{name: "405 with variables", method: http.MethodPost, path: "/items/1", wantCode: 405, wantAllow: "GET, HEAD, OPTIONS, PUT"},
+		{name: "options", method: http.MethodOptions, path: "/items", wantCode: 200, wantAllow: "GET, HEAD, OPTIONS, POST"},
+		{name: "head served by get", method: http.MethodHead, path: "/items", wantCode: 200},
+		{name: "explicit head route", method: http.MethodHead, path: "/ping", wantCode: 204},
+		{name: "unknown path", method: http.MethodDelete, path: "/missing", wantCode: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.GET("/items", textHandler("list"))
+			r.POST("/items", textHandler("create"))
+			r.GET("/items/{id}", textHandler("get"))
+			r.PUT("/items/{id}", textHandler("update"))
+			r.GET("/ping", textHandler("pong"))
+			r.HEAD("/ping", func(ctx *Context) (Response, error) { return ctx.NoContent() })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+			if tt.method == http.MethodHead && w.Body.Len() != 0 {
+				t.Errorf("HEAD response has a body: %q", w.Body)
+			}
+			if tt.method == http.MethodOptions {
+				var body struct {
+					Data []string `json:"data"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || len(body.Data) != 4 {
+					t.Errorf("OPTIONS body = %s", w.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestHeadResponseWriter(t *testing.T) {
+	r := New()
+	r.GET("/stream", func(ctx *Context) (Response, error) {
+		ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+		return ctx.Stream(func(w io.Writer) bool {
+			_, _ = io.WriteString(w, "data: 1\n\n")
+			return false
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/stream", nil))
+
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Fatalf("status = %d, body = %q", w.Code, w.Body)
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Content-Type = %q", w.Header().Get("Content-Type"))
+	}
+	if !w.Flushed {
+		t.Error("the flush of the GET handler did not reach the connection")
+	}
+
+	hw := &headResponseWriter{passthroughWriter{httptest.NewRecorder()}}
+	if _, _, err := hw.Hijack(); err == nil {
+		t.Error("Hijack() of a writer without http.Hijacker succeeded")
+	}
+	if err := hw.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Errorf("Push() = %v, want http.ErrNotSupported", err)
+	}
+}
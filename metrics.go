@@ -0,0 +1,234 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	unmatchedRoute = "unmatched"
+	otherMethod    = "_OTHER"
+)
+
+var (
+	DefaultDurationBuckets = prometheus.DefBuckets
+	DefaultSizeBuckets     = prometheus.ExponentialBuckets(128, 4, 8)
+)
+
+// MetricsMiddleware records prometheus metrics of the requests. The metrics are registered
+// into its own registry unless WithMetricsRegistry is used, so no global state is required.
+type MetricsMiddleware struct {
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+
+	requests *prometheus.CounterVec
+	inflight *prometheus.GaugeVec
+	duration *prometheus.HistogramVec
+	reqSize  *prometheus.HistogramVec
+	respSize *prometheus.HistogramVec
+}
+
+type metricsConfig struct {
+	namespace       string
+	subsystem       string
+	constLabels     prometheus.Labels
+	durationBuckets []float64
+	sizeBuckets     []float64
+	registerer      prometheus.Registerer
+	gatherer        prometheus.Gatherer
+}
+
+type MetricsOption func(*metricsConfig)
+
+// WithMetricsNamespace sets the namespace of the metric names, default is prouter.
+func WithMetricsNamespace(namespace string) MetricsOption {
+	return func(c *metricsConfig) {
+		c.namespace = namespace
+	}
+}
+
+func WithMetricsSubsystem(subsystem string) MetricsOption {
+	return func(c *metricsConfig) {
+		c.subsystem = subsystem
+	}
+}
+
+func WithMetricsConstLabels(labels prometheus.Labels) MetricsOption {
+	return func(c *metricsConfig) {
+		c.constLabels = labels
+	}
+}
+
+// WithDurationBuckets sets the buckets of the request duration histogram in seconds.
+func WithDurationBuckets(buckets ...float64) MetricsOption {
+	return func(c *metricsConfig) {
+		c.durationBuckets = buckets
+	}
+}
+
+// WithSizeBuckets sets the buckets of the request and response size histograms in bytes.
+func WithSizeBuckets(buckets ...float64) MetricsOption {
+	return func(c *metricsConfig) {
+		c.sizeBuckets = buckets
+	}
+}
+
+// WithMetricsRegistry registers the metrics into reg and exposes reg in the metrics handler.
+func WithMetricsRegistry(reg *prometheus.Registry) MetricsOption {
+	return func(c *metricsConfig) {
+		c.registerer = reg
+		c.gatherer = reg
+	}
+}
+
+func NewMetricsMiddleware(opts ...MetricsOption) *MetricsMiddleware {
+	conf := &metricsConfig{
+		namespace:       "prouter",
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	if conf.registerer == nil {
+		reg := prometheus.NewRegistry()
+		conf.registerer, conf.gatherer = reg, reg
+	}
+
+	opt := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{
+			Namespace:   conf.namespace,
+			Subsystem:   conf.subsystem,
+			Name:        name,
+			Help:        help,
+			ConstLabels: conf.constLabels,
+		}
+	}
+	histogramOpt := func(name, help string, buckets []float64) prometheus.HistogramOpts {
+		o := opt(name, help)
+		return prometheus.HistogramOpts{
+			Namespace:   o.Namespace,
+			Subsystem:   o.Subsystem,
+			Name:        o.Name,
+			Help:        o.Help,
+			ConstLabels: o.ConstLabels,
+			Buckets:     buckets,
+		}
+	}
+
+	labels := []string{"method", "route", "status", "error_component"}
+	m := &MetricsMiddleware{
+		registerer: conf.registerer,
+		gatherer:   conf.gatherer,
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts(opt("http_requests_total", "Total number of http requests.")),
+			labels,
+		),
+		inflight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts(opt("http_requests_in_flight", "Number of http requests being served.")),
+			[]string{"method", "route"},
+		),
+		duration: prometheus.NewHistogramVec(
+			histogramOpt("http_request_duration_seconds", "Duration of http requests in seconds.", conf.durationBuckets),
+			labels,
+		),
+		reqSize: prometheus.NewHistogramVec(
+			histogramOpt("http_request_size_bytes", "Size of http request bodies in bytes.", conf.sizeBuckets),
+			labels,
+		),
+		respSize: prometheus.NewHistogramVec(
+			histogramOpt("http_response_size_bytes", "Size of http response bodies in bytes.", conf.sizeBuckets),
+			labels,
+		),
+	}
+
+	m.registerer.MustRegister(m.requests, m.inflight, m.duration, m.reqSize, m.respSize)
+
+	return m
+}
+
+// Handler returns the prometheus text exposition handler of the registry.
+func (m *MetricsMiddleware) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{Registry: m.registerer})
+}
+
+// HandleFunc returns Handler as a HandleFunc, e.g. router.GET("/metrics", m.HandleFunc()).
+func (m *MetricsMiddleware) HandleFunc() HandleFunc {
+	return WrapHTTPHandler(m.Handler())
+}
+
+func metricsRoute(ctx *Context) string {
+	// the route template is used instead of the path to keep the label cardinality bounded
+	if route := ctx.RouteTemplate(); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
+
+// metricsMethod maps the methods not defined by RFC 9110 and RFC 5789 to _OTHER, the method
+// of an unmatched request is chosen by the client and would make the label cardinality unbounded.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
+
+func (m *MetricsMiddleware) observe(ctx *Context, err error, route string, spendTime time.Duration) {
+	statusCode := ctx.Writer.StatusCode()
+	if err != nil && statusCode == http.StatusOK && !ctx.Writer.Written() {
+		statusCode = http.StatusInternalServerError
+	}
+
+	var component string
+	if err != nil {
+		rErr := new(prouterError)
+		if errors.As(err, &rErr) {
+			component = string(rErr.Component())
+		}
+	}
+
+	labels := prometheus.Labels{
+		"method":          metricsMethod(ctx.Method),
+		"route":           route,
+		"status":          strconv.Itoa(statusCode),
+		"error_component": component,
+	}
+
+	reqSize := ctx.Request.ContentLength
+	if reqSize < 0 {
+		reqSize = 0
+	}
+
+	m.requests.With(labels).Inc()
+	m.duration.With(labels).Observe(spendTime.Seconds())
+	m.reqSize.With(labels).Observe(float64(reqSize))
+	m.respSize.With(labels).Observe(float64(ctx.Writer.Size()))
+}
+
+func (m *MetricsMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (resp Response, err error) {
+		start := time.Now()
+		route := metricsRoute(ctx)
+
+		inflight := m.inflight.WithLabelValues(metricsMethod(ctx.Method), route)
+		inflight.Inc()
+
+		ctx.onFinish(func() {
+			inflight.Dec()
+			m.observe(ctx, err, route, time.Since(start))
+		})
+
+		resp, err = handler.Handle(ctx)
+		return
+	})
+}
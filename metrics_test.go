@@ -0,0 +1,61 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantMethod string
+		wantRoute  string
+		wantStatus string
+	}{
+		{name: "matched", method: http.MethodGet, path: "/users/1", wantMethod: "GET", wantRoute: "/users/{id}", wantStatus: "200"},
+		{name: "unmatched", method: http.MethodGet, path: "/missing", wantMethod: "GET", wantRoute: unmatchedRoute, wantStatus: "404"},
+		{name: "standard method", method: http.MethodPatch, path: "/missing", wantMethod: "PATCH", wantRoute: unmatchedRoute, wantStatus: "404"},
+		{name: "custom method", method: "FOOBAR", path: "/missing", wantMethod: otherMethod, wantRoute: unmatchedRoute, wantStatus: "404"},
+		{name: "lower case method", method: "get", path: "/missing", wantMethod: otherMethod, wantRoute: unmatchedRoute, wantStatus: "404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			r := New()
+			r.UseMiddleware(NewMetricsMiddleware(WithMetricsRegistry(reg)))
+			r.GET("/users/{id}", func(*Context) (Response, error) { return SuccessResponse(nil), nil })
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			families, err := reg.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var found bool
+			for _, family := range families {
+				if family.GetName() != "prouter_http_requests_total" {
+					continue
+				}
+				for _, metric := range family.GetMetric() {
+					labels := make(map[string]string)
+					for _, pair := range metric.GetLabel() {
+						labels[pair.GetName()] = pair.GetValue()
+					}
+					if labels["method"] != tt.wantMethod || labels["route"] != tt.wantRoute || labels["status"] != tt.wantStatus {
+						t.Errorf("labels = %v", labels)
+					}
+					found = true
+				}
+			}
+			if !found {
+				t.Fatal("http_requests_total was not recorded")
+			}
+		})
+	}
+}
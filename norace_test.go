@@ -0,0 +1,5 @@
+//go:build !race
+
+package prouter
+
+const raceEnabled = false
@@ -0,0 +1,239 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultVarPattern = "[^/]+"
+
+// Converter converts a path variable declared with its name, e.g. {id:int}. A request whose
+// variable can not be converted is answered with 400 Bad Request.
+//
+// The name of a converter is looked up before the pattern of a variable is taken as a regexp,
+// so the names of the built-in converters int, float, uuid, slug, date and time are reserved:
+// {d:date} is the date converter and never the regexp "date".
+type Converter struct {
+	// Pattern is the regexp the variable has to match, it must not contain capturing groups
+	Pattern string
+	// Type and Format describe the converted value as an OpenAPI schema, e.g. integer and int
+	Type   string
+	Format string
+	// Convert converts the variable, the variable is kept as a string when it is nil
+	Convert func(string) (any, error)
+}
+
+// builtinConverters are the names which can not be registered again.
+var builtinConverters = []string{"int", "float", "uuid", "slug", "date", "time"}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[string]Converter{
+		"int": {
+			Pattern: "-?[0-9]+",
+			Type:    "integer",
+			// the value is an int, which has the size of the platform
+			Format:  "int",
+			Convert: func(s string) (any, error) { return strconv.Atoi(s) },
+		},
+		"float": {
+			Pattern: `-?[0-9]+(?:\.[0-9]+)?`,
+			Type:    "number",
+			Format:  "double",
+			Convert: func(s string) (any, error) { return strconv.ParseFloat(s, 64) },
+		},
+		"uuid": {
+			Pattern: "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}",
+			Type:    "string",
+			Format:  "uuid",
+			Convert: func(s string) (any, error) { return uuid.Parse(s) },
+		},
+		"slug": {
+			Pattern: "[a-z0-9]+(?:-[a-z0-9]+)*",
+			Type:    "string",
+		},
+		"date": {
+			Pattern: "[0-9]{4}-[0-9]{2}-[0-9]{2}",
+			Type:    "string",
+			Format:  "date",
+			Convert: func(s string) (any, error) { return time.Parse(time.DateOnly, s) },
+		},
+		"time": {
+			Pattern: defaultVarPattern,
+			Type:    "string",
+			Format:  "date-time",
+			Convert: func(s string) (any, error) { return time.Parse(time.RFC3339, s) },
+		},
+	}
+)
+
+// RegisterConverter registers a converter usable in the path templates of the routes registered afterwards.
+// It panics on an invalid pattern or the name of a built-in converter, see Converter.
+func RegisterConverter(name string, conv Converter) {
+	if slices.Contains(builtinConverters, name) {
+		panic(fmt.Sprintf("converter %s: the name of a built-in converter is reserved", name))
+	}
+	if conv.Pattern == "" {
+		conv.Pattern = defaultVarPattern
+	}
+	re, err := regexp.Compile(conv.Pattern)
+	if err != nil {
+		panic(fmt.Sprintf("converter %s: %v", name, err))
+	}
+	if re.NumSubexp() > 0 {
+		panic(fmt.Sprintf("converter %s: pattern must not contain capturing groups", name))
+	}
+	if conv.Type == "" {
+		conv.Type = "string"
+	}
+
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[name] = conv
+}
+
+func lookupConverter(name string) (Converter, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	conv, ok := converters[name]
+	return conv, ok
+}
+
+// varPattern returns the regexp of a variable declared with expr, which is a pattern or a converter name.
+func varPattern(expr string) string {
+	if expr == "" {
+		return defaultVarPattern
+	}
+	if conv, ok := lookupConverter(expr); ok {
+		return conv.Pattern
+	}
+	return expr
+}
+
+// ParamInfo describes a path variable of a route.
+type ParamInfo struct {
+	Name      string
+	Converter string
+	Pattern   string
+	// Type and Format describe the variable as an OpenAPI schema
+	Type   string
+	Format string
+}
+
+// compileTemplate replaces the converter names of a path template by their patterns, and returns the
+// variables of the template and the converters of the converted ones.
+func compileTemplate(tpl string) (string, []ParamInfo, map[string]Converter) {
+	var (
+		params []ParamInfo
+		convs  map[string]Converter
+	)
+	compiled, ok := rewriteTemplate(tpl, func(s string) string { return s }, func(name, expr string) string {
+		param := ParamInfo{Name: name, Pattern: varPattern(expr), Type: "string"}
+		conv, isConv := lookupConverter(expr)
+		if isConv {
+			param.Converter, param.Type, param.Format = expr, conv.Type, conv.Format
+		}
+		params = append(params, param)
+
+		if !isConv {
+			if expr == "" {
+				return "{" + name + "}"
+			}
+			return "{" + name + ":" + expr + "}"
+		}
+		if conv.Convert != nil {
+			if convs == nil {
+				convs = make(map[string]Converter)
+			}
+			convs[name] = conv
+		}
+		return "{" + name + ":" + conv.Pattern + "}"
+	})
+	if !ok {
+		return tpl, nil, nil
+	}
+	return compiled, params, convs
+}
+
+// convertVars converts the variables of the route before handler runs.
+func convertVars(convs map[string]Converter, handler handlerFunc) handlerFunc {
+	return &wrapHandler{
+		name: handler.Name(),
+		handler: func(ctx *Context) (Response, error) {
+			ctx.params = make(map[string]any, len(convs))
+			for name, conv := range convs {
+				val, err := conv.Convert(ctx.vars[name])
+				if err != nil {
+					return nil, invalidVar(name, err)
+				}
+				ctx.params[name] = val
+			}
+			return handler.Handle(ctx)
+		},
+	}
+}
+
+func invalidVar(name string, err error) Error {
+	return NewErr(http.StatusBadRequest, err, "invalid path parameter: "+name).
+		SetComponent(ErrProuter).
+		SetResponseType(BadRequest)
+}
+
+// VarValue returns the variable converted by the converter of its template, or the raw string
+// if it has no converter.
+func (c *Context) VarValue(key string) any {
+	if val, ok := c.params[key]; ok {
+		return val
+	}
+	return c.vars[key]
+}
+
+// VarInt returns the variable as an int, the error is a 400 response which can be returned by the handler.
+func (c *Context) VarInt(key string) (int, error) {
+	if val, ok := c.params[key].(int); ok {
+		return val, nil
+	}
+	val, err := strconv.Atoi(c.vars[key])
+	if err != nil {
+		return 0, invalidVar(key, err)
+	}
+	return val, nil
+}
+
+// VarUUID returns the variable as an uuid, the error is a 400 response which can be returned by the handler.
+func (c *Context) VarUUID(key string) (uuid.UUID, error) {
+	if val, ok := c.params[key].(uuid.UUID); ok {
+		return val, nil
+	}
+	val, err := uuid.Parse(c.vars[key])
+	if err != nil {
+		return uuid.Nil, invalidVar(key, err)
+	}
+	return val, nil
+}
+
+// VarTime returns the variable as a time, a variable without a date or time converter is parsed with
+// layout, RFC 3339 by default. The error is a 400 response which can be returned by the handler.
+func (c *Context) VarTime(key string, layout ...string) (time.Time, error) {
+	if val, ok := c.params[key].(time.Time); ok {
+		return val, nil
+	}
+
+	l := time.RFC3339
+	if len(layout) > 0 {
+		l = layout[0]
+	}
+	val, err := time.Parse(l, strings.TrimSpace(c.vars[key]))
+	if err != nil {
+		return time.Time{}, invalidVar(key, err)
+	}
+	return val, nil
+}
@@ -0,0 +1,198 @@
+package prouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConverters(t *testing.T) {
+	valueHandler := func(ctx *Context) (Response, error) {
+		val := ctx.VarValue("v")
+		return ctx.String(http.StatusOK, fmt.Sprintf("%T %v", val, val))
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "int", template: "/v/{v:int}", target: "/v/-12", wantCode: 200, wantBody: "int -12"},
+		{name: "int pattern mismatch", template: "/v/{v:int}", target: "/v/abc", wantCode: 404},
+		{name: "int overflow", template: "/v/{v:int}", target: "/v/99999999999999999999", wantCode: 400},
+		{name: "float", template: "/v/{v:float}", target: "/v/1.5", wantCode: 200, wantBody: "float64 1.5"},
+		{
+			name:     "uuid",
+			template: "/v/{v:uuid}",
+			target:   "/v/6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			wantCode: 200,
+			wantBody: "uuid.UUID 6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		},
+		{name: "slug", template: "/v/{v:slug}", target: "/v/hello-world", wantCode: 200, wantBody: "string hello-world"},
+		{name: "slug pattern mismatch", template: "/v/{v:slug}", target: "/v/Hello", wantCode: 404},
+		{name: "date", template: "/v/{v:date}", target: "/v/2024-02-29", wantCode: 200, wantBody: "time.Time 2024-02-29 00:00:00 +0000 UTC"},
+		{name: "invalid date", template: "/v/{v:date}", target: "/v/2024-13-45", wantCode: 400},
+		{name: "time", template: "/v/{v:time}", target: "/v/2024-02-29T10:00:00Z", wantCode: 200, wantBody: "time.Time 2024-02-29 10:00:00 +0000 UTC"},
+		{name: "pattern", template: "/v/{v:[0-9]+}", target: "/v/12", wantCode: 200, wantBody: "string 12"},
+		{name: "plain variable", template: "/v/{v}", target: "/v/12", wantCode: 200, wantBody: "string 12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.GET(tt.template, valueHandler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestVarAccessors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		target   string
+		handler  HandleFunc
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "int of a plain variable",
+			template: "/v/{v}",
+			target:   "/v/7",
+			handler: func(ctx *Context) (Response, error) {
+				v, err := ctx.VarInt("v")
+				if err != nil {
+					return nil, err
+				}
+				return ctx.String(http.StatusOK, fmt.Sprint(v+1))
+			},
+			wantCode: 200,
+			wantBody: "8",
+		},
+		{
+			name:     "invalid int",
+			template: "/v/{v}",
+			target:   "/v/seven",
+			handler: func(ctx *Context) (Response, error) {
+				_, err := ctx.VarInt("v")
+				return nil, err
+			},
+			wantCode: 400,
+		},
+		{
+			name:     "invalid uuid",
+			template: "/v/{v}",
+			target:   "/v/not-an-uuid",
+			handler: func(ctx *Context) (Response, error) {
+				_, err := ctx.VarUUID("v")
+				return nil, err
+			},
+			wantCode: 400,
+		},
+		{
+			name:     "time with a layout",
+			template: "/v/{v}",
+			target:   "/v/02.01.2024",
+			handler: func(ctx *Context) (Response, error) {
+				v, err := ctx.VarTime("v", "02.01.2006")
+				if err != nil {
+					return nil, err
+				}
+				return ctx.String(http.StatusOK, v.Format(time.DateOnly))
+			},
+			wantCode: 200,
+			wantBody: "2024-01-02",
+		},
+		{
+			name:     "time of a date converter",
+			template: "/v/{v:date}",
+			target:   "/v/2024-01-02",
+			handler: func(ctx *Context) (Response, error) {
+				v, err := ctx.VarTime("v")
+				if err != nil {
+					return nil, err
+				}
+				return ctx.String(http.StatusOK, v.Format(time.DateOnly))
+			},
+			wantCode: 200,
+			wantBody: "2024-01-02",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.GET(tt.template, tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter("hex", Converter{
+		Pattern: "[0-9a-f]+",
+		Format:  "hex",
+	})
+
+	r := New()
+	r.GET("/colors/{c:hex}", varHandler("c"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/colors/ff00aa", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ff00aa" {
+		t.Errorf("status = %d, body = %q", w.Code, w.Body)
+	}
+
+	params := r.RouteTable()[0].Params
+	want := ParamInfo{Name: "c", Converter: "hex", Pattern: "[0-9a-f]+", Type: "string", Format: "hex"}
+	if len(params) != 1 || params[0] != want {
+		t.Errorf("Params = %+v, want %+v", params, want)
+	}
+
+	for _, pattern := range []string{"[0-9", "([0-9]+)"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("pattern %q did not panic", pattern)
+				}
+			}()
+			RegisterConverter("invalid", Converter{Pattern: pattern})
+		}()
+	}
+
+	for _, name := range builtinConverters {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("the built-in converter %s was replaced", name)
+				}
+			}()
+			RegisterConverter(name, Converter{Pattern: "[a-z]+"})
+		}()
+	}
+	if conv, _ := lookupConverter("int"); conv.Pattern != "-?[0-9]+" || conv.Format != "int" {
+		t.Errorf("int converter = %+v", conv)
+	}
+}
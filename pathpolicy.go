@@ -0,0 +1,299 @@
+package prouter
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// TrailingSlashPolicy decides how a path which only differs from a route by a trailing slash is handled.
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashStrict treats /users and /users/ as different paths.
+	TrailingSlashStrict TrailingSlashPolicy = iota
+	// TrailingSlashRedirect redirects to the registered path with 301 Moved Permanently.
+	TrailingSlashRedirect
+	// TrailingSlashRedirectKeepMethod redirects to the registered path with 308 Permanent Redirect,
+	// which keeps the method and the body of the request.
+	TrailingSlashRedirectKeepMethod
+)
+
+func (p TrailingSlashPolicy) String() string {
+	switch p {
+	case TrailingSlashRedirect:
+		return "redirect-301"
+	case TrailingSlashRedirectKeepMethod:
+		return "redirect-308"
+	default:
+		return "strict"
+	}
+}
+
+func (p TrailingSlashPolicy) redirectCode() int {
+	if p == TrailingSlashRedirectKeepMethod {
+		return http.StatusPermanentRedirect
+	}
+	return http.StatusMovedPermanently
+}
+
+// pathPolicy is the path handling of the routes registered on a group, the zero value
+// matches the defaults of gorilla/mux.
+type pathPolicy struct {
+	trailingSlash   TrailingSlashPolicy
+	skipClean       bool
+	caseInsensitive bool
+	encodedPath     bool
+}
+
+// TrailingSlash sets the trailing slash policy of the routes registered on the group afterwards.
+func (rg *RouterGroup) TrailingSlash(policy TrailingSlashPolicy) *RouterGroup {
+	rg.policy.trailingSlash = policy
+	return rg
+}
+
+// CleanPath sets whether paths containing // or .. are redirected to their clean form
+// when they lead to a route of the group, it is enabled by default.
+func (rg *RouterGroup) CleanPath(enabled bool) *RouterGroup {
+	rg.policy.skipClean = !enabled
+	return rg
+}
+
+// CaseInsensitive sets whether the routes registered on the group afterwards match paths regardless of case.
+// The exact case is tried first, so it only costs when no route matches.
+func (rg *RouterGroup) CaseInsensitive(enabled bool) *RouterGroup {
+	rg.policy.caseInsensitive = enabled
+	return rg
+}
+
+// UseEncodedPath matches the routes registered on the group afterwards against the encoded path,
+// e.g. /users/a%2Fb matches /users/{name} with name a%2Fb.
+func (rg *RouterGroup) UseEncodedPath() *RouterGroup {
+	rg.policy.encodedPath = true
+	rg.router.UseEncodedPath()
+	return rg
+}
+
+func WithTrailingSlash(policy TrailingSlashPolicy) RouterOption {
+	return func(v *Prouter) {
+		v.TrailingSlash(policy)
+	}
+}
+
+func WithCleanPath(enabled bool) RouterOption {
+	return func(v *Prouter) {
+		v.CleanPath(enabled)
+	}
+}
+
+func WithCaseInsensitive(enabled bool) RouterOption {
+	return func(v *Prouter) {
+		v.CaseInsensitive(enabled)
+	}
+}
+
+func WithEncodedPath() RouterOption {
+	return func(v *Prouter) {
+		v.UseEncodedPath()
+	}
+}
+
+// cleanPath returns the canonical path of p, the trailing slash is kept.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+func requestPath(r *http.Request, encoded bool) string {
+	if encoded {
+		return r.URL.EscapedPath()
+	}
+	return r.URL.Path
+}
+
+// withPath returns a shallow copy of r whose url has path p, p is encoded when encoded is set.
+func withPath(r *http.Request, p string, encoded bool) *http.Request {
+	u := *r.URL
+	if encoded {
+		u.RawPath = p
+		if unescaped, err := url.PathUnescape(p); err == nil {
+			u.Path = unescaped
+		}
+	} else {
+		u.Path = p
+		u.RawPath = ""
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = &u
+	return r2
+}
+
+func redirectTo(w http.ResponseWriter, r *http.Request, code int) {
+	w.Header().Set("Location", r.URL.String())
+	w.WriteHeader(code)
+}
+
+// match matches r against the routes and returns the registered route it leads to.
+func (v *Prouter) match(r *http.Request) (*routeEntry, *mux.RouteMatch) {
+	var match mux.RouteMatch
+	if !v.router.Match(r, &match) || match.MatchErr != nil || match.Route == nil {
+		return nil, nil
+	}
+	return v.routeIndex[match.Route], &match
+}
+
+// redirectCleanPath redirects a path containing // or .. to its clean form when the route it leads to,
+// or the router if it leads to none, has path cleaning enabled. A route with path cleaning disabled
+// which matches the path as it is serves it.
+func (v *Prouter) redirectCleanPath(w http.ResponseWriter, r *http.Request) bool {
+	encoded := v.policy.encodedPath
+	p := requestPath(r, encoded)
+	cp := cleanPath(p)
+	if cp == p {
+		return false
+	}
+	if entry, _ := v.match(r); entry != nil && entry.policy.skipClean {
+		return false
+	}
+
+	r2 := withPath(r, cp, encoded)
+	policy := v.policy
+	if entry, _ := v.match(r2); entry != nil {
+		policy = entry.policy
+	}
+	if policy.skipClean {
+		return false
+	}
+
+	redirectTo(w, r2, http.StatusMovedPermanently)
+	return true
+}
+
+// serveNotFound applies the trailing slash and case insensitive policies to a request
+// which matched no route, it reports whether the request was served.
+func (v *Prouter) serveNotFound(w http.ResponseWriter, r *http.Request) bool {
+	if v.redirectTrailingSlash(w, r) {
+		return true
+	}
+	return v.serveCaseInsensitive(w, r)
+}
+
+func (v *Prouter) redirectTrailingSlash(w http.ResponseWriter, r *http.Request) bool {
+	encoded := v.policy.encodedPath
+	p := requestPath(r, encoded)
+	if p == "/" {
+		return false
+	}
+
+	if strings.HasSuffix(p, "/") {
+		p = strings.TrimSuffix(p, "/")
+	} else {
+		p += "/"
+	}
+
+	r2 := withPath(r, p, encoded)
+	entry, _ := v.match(r2)
+	if entry == nil || entry.policy.trailingSlash == TrailingSlashStrict {
+		return false
+	}
+
+	redirectTo(w, r2, entry.policy.trailingSlash.redirectCode())
+	return true
+}
+
+// serveCaseInsensitive serves r with the first case insensitive route whose template matches its path.
+// The path is rebuilt in the case of the template and matched again, so that the other matchers
+// of the route, such as the method and the host, still apply.
+func (v *Prouter) serveCaseInsensitive(w http.ResponseWriter, r *http.Request) bool {
+	for _, entry := range v.routes {
+		if !entry.policy.caseInsensitive {
+			continue
+		}
+
+		pairs, ok := entry.matchFold(requestPath(r, entry.policy.encodedPath))
+		if !ok {
+			continue
+		}
+		u, err := entry.route.URLPath(pairs...)
+		if err != nil {
+			continue
+		}
+
+		matched, match := v.match(withPath(r, u.Path, false))
+		if matched != entry {
+			continue
+		}
+
+		match.Handler.ServeHTTP(w, mux.SetURLVars(r, match.Vars))
+		return true
+	}
+	return false
+}
+
+// foldRegexp compiles the path template into a case insensitive regexp, the variables are
+// captured in the order of the template.
+func foldRegexp(tpl string) (*regexp.Regexp, []string) {
+	var names []string
+	pattern, ok := rewriteTemplate(tpl, regexp.QuoteMeta, func(name, expr string) string {
+		names = append(names, name)
+		return "(" + varPattern(expr) + ")"
+	})
+	if !ok {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile("(?i)^" + pattern + "$")
+	if err != nil {
+		return nil, nil
+	}
+	return re, names
+}
+
+// rewriteTemplate rewrites the literal parts and the variables of a path template, expr is empty
+// for a variable without a pattern. It reports false when the braces are unbalanced.
+func rewriteTemplate(tpl string, literal func(string) string, variable func(name, expr string) string) (string, bool) {
+	var ret strings.Builder
+	for {
+		start := strings.IndexByte(tpl, '{')
+		if start < 0 {
+			break
+		}
+		end, level := -1, 0
+		for i := start; i < len(tpl) && end < 0; i++ {
+			switch tpl[i] {
+			case '{':
+				level++
+			case '}':
+				if level--; level == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			return "", false
+		}
+
+		name, expr, _ := strings.Cut(tpl[start+1:end], ":")
+		ret.WriteString(literal(tpl[:start]))
+		ret.WriteString(variable(strings.TrimSpace(name), expr))
+		tpl = tpl[end+1:]
+	}
+
+	ret.WriteString(literal(tpl))
+	return ret.String(), true
+}
@@ -0,0 +1,186 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func varHandler(key string) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		return ctx.String(http.StatusOK, ctx.Var(key))
+	}
+}
+
+func TestPathPolicies(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []RouterOption
+		setup        func(r *Prouter)
+		method       string
+		target       string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:     "trailing slash strict",
+			setup:    func(r *Prouter) { r.GET("/users", textHandler("users")) },
+			target:   "/users/",
+			wantCode: 404,
+		},
+		{
+			name:         "trailing slash redirect",
+			opts:         []RouterOption{WithTrailingSlash(TrailingSlashRedirect)},
+			setup:        func(r *Prouter) { r.GET("/users", textHandler("users")) },
+			target:       "/users/?page=2",
+			wantCode:     301,
+			wantLocation: "/users?page=2",
+		},
+		{
+			name:         "trailing slash added",
+			opts:         []RouterOption{WithTrailingSlash(TrailingSlashRedirect)},
+			setup:        func(r *Prouter) { r.GET("/users/", textHandler("users")) },
+			target:       "/users",
+			wantCode:     301,
+			wantLocation: "/users/",
+		},
+		{
+			name:         "trailing slash redirect keeps method",
+			opts:         []RouterOption{WithTrailingSlash(TrailingSlashRedirectKeepMethod)},
+			setup:        func(r *Prouter) { r.POST("/users", textHandler("users")) },
+			method:       http.MethodPost,
+			target:       "/users/",
+			wantCode:     308,
+			wantLocation: "/users",
+		},
+		{
+			name: "trailing slash policy of a group",
+			setup: func(r *Prouter) {
+				r.GET("/a", textHandler("a"))
+				r.Group("/api").TrailingSlash(TrailingSlashRedirect).GET("/b", textHandler("b"))
+			},
+			target:       "/api/b/",
+			wantCode:     301,
+			wantLocation: "/api/b",
+		},
+		{
+			name: "trailing slash strict outside the group",
+			setup: func(r *Prouter) {
+				r.GET("/a", textHandler("a"))
+				r.Group("/api").TrailingSlash(TrailingSlashRedirect).GET("/b", textHandler("b"))
+			},
+			target:   "/a/",
+			wantCode: 404,
+		},
+		{
+			name:         "clean path",
+			setup:        func(r *Prouter) { r.GET("/users/{id}", varHandler("id")) },
+			target:       "/users//../users/1",
+			wantCode:     301,
+			wantLocation: "/users/1",
+		},
+		{
+			name: "clean path disabled by the group",
+			setup: func(r *Prouter) {
+				r.Group("/raw").CleanPath(false).GET("/{rest:.*}", varHandler("rest"))
+			},
+			target:   "/raw/x//y",
+			wantCode: 200,
+			wantBody: "x//y",
+		},
+		{
+			name:     "clean path disabled by the router",
+			opts:     []RouterOption{WithCleanPath(false)},
+			setup:    func(r *Prouter) { r.GET("/users/{id}", varHandler("id")) },
+			target:   "/users//1",
+			wantCode: 404,
+		},
+		{
+			name:     "case sensitive by default",
+			setup:    func(r *Prouter) { r.GET("/Users/{id}", varHandler("id")) },
+			target:   "/users/Ab",
+			wantCode: 404,
+		},
+		{
+			name:     "case insensitive",
+			opts:     []RouterOption{WithCaseInsensitive(true)},
+			setup:    func(r *Prouter) { r.GET("/Users/{id}", varHandler("id")) },
+			target:   "/USERS/Ab",
+			wantCode: 200,
+			wantBody: "Ab",
+		},
+		{
+			name:     "case insensitive keeps the method",
+			opts:     []RouterOption{WithCaseInsensitive(true)},
+			setup:    func(r *Prouter) { r.GET("/users", textHandler("users")) },
+			method:   http.MethodPost,
+			target:   "/USERS",
+			wantCode: 404,
+		},
+		{
+			name:     "exact case first",
+			opts:     []RouterOption{WithCaseInsensitive(true)},
+			setup:    func(r *Prouter) { r.GET("/a", textHandler("lower")); r.GET("/A", textHandler("upper")) },
+			target:   "/A",
+			wantCode: 200,
+			wantBody: "upper",
+		},
+		{
+			name:     "decoded path",
+			setup:    func(r *Prouter) { r.GET("/files/{name}", varHandler("name")) },
+			target:   "/files/a%2Fb",
+			wantCode: 404,
+		},
+		{
+			name:     "encoded path",
+			opts:     []RouterOption{WithEncodedPath()},
+			setup:    func(r *Prouter) { r.GET("/files/{name}", varHandler("name")) },
+			target:   "/files/a%2Fb",
+			wantCode: 200,
+			wantBody: "a%2Fb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.opts...)
+			tt.setup(r)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(method, tt.target, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRouteTablePolicies(t *testing.T) {
+	r := New(WithTrailingSlash(TrailingSlashRedirect))
+	r.GET("/a", textHandler("a"))
+	r.Group("/api").CleanPath(false).CaseInsensitive(true).GET("/b", textHandler("b"), WithRouteName("b"))
+
+	table := r.RouteTable()
+	if len(table) != 2 {
+		t.Fatalf("RouteTable() = %v", table)
+	}
+	a, b := table[0], table[1]
+	if a.Path != "/a" || a.TrailingSlash != TrailingSlashRedirect || !a.CleanPath || a.CaseInsensitive {
+		t.Errorf("route a = %+v", a)
+	}
+	if b.Path != "/api/b" || b.Name != "b" || b.TrailingSlash != TrailingSlashRedirect || b.CleanPath || !b.CaseInsensitive {
+		t.Errorf("route b = %+v", b)
+	}
+}
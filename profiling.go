@@ -0,0 +1,110 @@
+package prouter
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+var processStartTime = time.Now()
+
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+type RuntimeSummary struct {
+	GoVersion    string `json:"goVersion"`
+	NumCPU       int    `json:"numCpu"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	NumGoroutine int    `json:"numGoroutine"`
+	Uptime       string `json:"uptime"`
+
+	HeapAlloc   uint64 `json:"heapAlloc"`
+	HeapInuse   uint64 `json:"heapInuse"`
+	HeapObjects uint64 `json:"heapObjects"`
+	HeapSys     uint64 `json:"heapSys"`
+	StackInuse  uint64 `json:"stackInuse"`
+	Sys         uint64 `json:"sys"`
+	TotalAlloc  uint64 `json:"totalAlloc"`
+	NumGC       uint32 `json:"numGc"`
+	LastGC      string `json:"lastGc,omitempty"`
+	PauseTotal  string `json:"pauseTotal"`
+	GCPercent   uint64 `json:"gcPercent"`
+	MemoryLimit uint64 `json:"memoryLimit"`
+}
+
+func sampleValue(s metrics.Sample) uint64 {
+	if s.Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s.Value.Uint64()
+}
+
+func runtimeSummary(*Context) (Response, error) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	samples := []metrics.Sample{{Name: "/gc/gogc:percent"}, {Name: "/gc/gomemlimit:bytes"}}
+	metrics.Read(samples)
+
+	summary := &RuntimeSummary{
+		GoVersion:    runtime.Version(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumGoroutine: runtime.NumGoroutine(),
+		Uptime:       time.Since(processStartTime).Round(time.Second).String(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapInuse:    m.HeapInuse,
+		HeapObjects:  m.HeapObjects,
+		HeapSys:      m.HeapSys,
+		StackInuse:   m.StackInuse,
+		Sys:          m.Sys,
+		TotalAlloc:   m.TotalAlloc,
+		NumGC:        m.NumGC,
+		PauseTotal:   time.Duration(m.PauseTotalNs).String(),
+		GCPercent:    sampleValue(samples[0]),
+		MemoryLimit:  sampleValue(samples[1]),
+	}
+	if m.LastGC > 0 {
+		summary.LastGC = time.Unix(0, int64(m.LastGC)).Format(time.RFC3339)
+	}
+
+	return SuccessResponse(summary), nil
+}
+
+func wrapNamedHTTPHandler(name string, h http.Handler) handlerFunc {
+	return &wrapHandler{name: name, handler: WrapHTTPHandler(h)}
+}
+
+// loopbackNetworks are allowed to reach the profiling endpoints when no guard is given.
+var loopbackNetworks = []string{"127.0.0.0/8", "::1"}
+
+// EnableProfiling registers the net/http/pprof handlers, expvar and a runtime summary under prefix.
+// The endpoints run through the middlewares of the group and are protected by guard, see
+// NewIPAllowlistMiddleware and NewBasicAuthMiddleware. A nil guard only allows loopback clients.
+//
+//	{prefix}/            pprof index
+//	{prefix}/{profile}   named profiles such as heap and goroutine
+//	{prefix}/vars        expvar
+//	{prefix}/runtime     goroutine, heap and gc summary
+func (rg *RouterGroup) EnableProfiling(prefix string, guard Middleware) {
+	if guard == nil {
+		guard = NewIPAllowlistMiddleware(loopbackNetworks...)
+	}
+	g := rg.Group(prefix)
+	g.UseMiddleware(guard)
+
+	g.handleRoute(http.MethodGet, "/", wrapNamedHTTPHandler("pprof.Index", http.HandlerFunc(pprof.Index)))
+	g.handleRoute(http.MethodGet, "/cmdline", wrapNamedHTTPHandler("pprof.Cmdline", http.HandlerFunc(pprof.Cmdline)))
+	g.handleRoute(http.MethodGet, "/profile", wrapNamedHTTPHandler("pprof.Profile", http.HandlerFunc(pprof.Profile)))
+	g.handleRoute(http.MethodGet, "/symbol", wrapNamedHTTPHandler("pprof.Symbol", http.HandlerFunc(pprof.Symbol)))
+	g.handleRoute(http.MethodPost, "/symbol", wrapNamedHTTPHandler("pprof.Symbol", http.HandlerFunc(pprof.Symbol)))
+	g.handleRoute(http.MethodGet, "/trace", wrapNamedHTTPHandler("pprof.Trace", http.HandlerFunc(pprof.Trace)))
+	for _, name := range pprofProfiles {
+		g.handleRoute(http.MethodGet, "/"+name, wrapNamedHTTPHandler("pprof."+name, pprof.Handler(name)))
+	}
+
+	g.handleRoute(http.MethodGet, "/vars", wrapNamedHTTPHandler("expvar.Handler", expvar.Handler()))
+	g.handleRoute(http.MethodGet, "/runtime", &wrapHandler{name: "RuntimeSummary", handler: runtimeSummary})
+}
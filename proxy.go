@@ -0,0 +1,262 @@
+package prouter
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Trusted platform headers which carry the client ip set by the platform's edge proxy.
+// The proxy addresses of the platform must be trusted with WithTrustedProxies as well.
+const (
+	PlatformCloudflare      = "CF-Connecting-IP"
+	PlatformGoogleAppEngine = "X-Appengine-Remote-Addr"
+	PlatformFlyIO           = "Fly-Client-IP"
+	PlatformAkamai          = "True-Client-IP"
+)
+
+// forwardedElement is one hop of the RFC 7239 Forwarded header.
+type forwardedElement struct {
+	forIP string
+	proto string
+	host  string
+}
+
+// parseForwarded parses the Forwarded headers, the elements are in the order of the hops.
+func parseForwarded(values []string) []forwardedElement {
+	var elements []forwardedElement
+	for _, value := range values {
+		for _, part := range splitQuoted(value, ',') {
+			var elem forwardedElement
+			for _, pair := range splitQuoted(part, ';') {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "for":
+					elem.forIP = stripPort(val)
+				case "proto":
+					elem.proto = strings.ToLower(val)
+				case "host":
+					elem.host = val
+				}
+			}
+			elements = append(elements, elem)
+		}
+	}
+	return elements
+}
+
+// splitQuoted splits s by sep outside double quotes.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stripPort removes the port and the brackets from an ip, e.g. [2001:db8::1]:4711.
+func stripPort(s string) string {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return host
+	}
+	return strings.Trim(s, "[]")
+}
+
+func splitList(values []string) []string {
+	var ret []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				ret = append(ret, item)
+			}
+		}
+	}
+	return ret
+}
+
+// proxyResolver resolves the client ip, scheme and host of a request. The forwarding headers are only
+// used when the request comes from a trusted proxy, otherwise they could be spoofed.
+type proxyResolver struct {
+	trusted []*net.IPNet
+	// platformHeader is checked before the standard forwarding headers
+	platformHeader string
+}
+
+func (res *proxyResolver) isTrusted(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range res.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromTrustedProxy reports whether the direct peer of the request is a trusted proxy.
+func (res *proxyResolver) fromTrustedProxy(r *http.Request) bool {
+	return len(res.trusted) > 0 && res.isTrusted(net.ParseIP(remoteIP(r)))
+}
+
+// clientHop walks the hops from the nearest one and returns the index of the first untrusted address,
+// or of the farthest address when all of them are trusted. It is -1 when the nearest hop is not an ip.
+func (res *proxyResolver) clientHop(hops []string) int {
+	hop := -1
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(stripPort(hops[i]))
+		if ip == nil {
+			// an unknown or obfuscated hop can not be trusted past
+			return hop
+		}
+		hop = i
+		if !res.isTrusted(ip) {
+			return hop
+		}
+	}
+	return hop
+}
+
+// rightmostUntrusted returns the address of the client hop, see clientHop.
+func (res *proxyResolver) rightmostUntrusted(hops []string) string {
+	if i := res.clientHop(hops); i >= 0 {
+		return net.ParseIP(stripPort(hops[i])).String()
+	}
+	return ""
+}
+
+func (res *proxyResolver) ClientIP(r *http.Request) string {
+	remote := clientIP(r)
+	if !res.fromTrustedProxy(r) {
+		return remote
+	}
+
+	if res.platformHeader != "" {
+		if ip := net.ParseIP(stripPort(r.Header.Get(res.platformHeader))); ip != nil {
+			return ip.String()
+		}
+	}
+
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		var hops []string
+		for _, elem := range parseForwarded(values) {
+			hops = append(hops, elem.forIP)
+		}
+		if ip := res.rightmostUntrusted(hops); ip != "" {
+			return ip
+		}
+	}
+
+	if hops := splitList(r.Header.Values("X-Forwarded-For")); len(hops) > 0 {
+		if ip := res.rightmostUntrusted(hops); ip != "" {
+			return ip
+		}
+	}
+
+	if ip := net.ParseIP(stripPort(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+
+	return remote
+}
+
+// WithTrustedProxies trusts the forwarding headers set by proxies in the given networks.
+// It accepts CIDRs and single IPs and panics when an entry can not be parsed.
+func WithTrustedProxies(cidrs ...string) RouterOption {
+	return func(v *Prouter) {
+		for _, cidr := range cidrs {
+			v.proxyResolver.trusted = append(v.proxyResolver.trusted, mustParseNetwork(cidr))
+		}
+	}
+}
+
+// WithTrustedPlatform reads the client ip from a platform header such as PlatformCloudflare
+// when the request comes from a trusted proxy.
+func WithTrustedPlatform(header string) RouterOption {
+	return func(v *Prouter) {
+		v.proxyResolver.platformHeader = header
+	}
+}
+
+// ClientIP resolves the client ip of r with the trusted proxy configuration of the router.
+func (v *Prouter) ClientIP(r *http.Request) string {
+	return v.proxyResolver.ClientIP(r)
+}
+
+// forwardedValue returns the value of the Forwarded element or the X-Forwarded header written by the
+// proxy facing the client. The hops are walked from the nearest one like the client ip, so that
+// a value sent by the client in front of the trusted proxies is never used.
+func (res *proxyResolver) forwardedValue(r *http.Request, param func(forwardedElement) string, header string) string {
+	if elements := parseForwarded(r.Header.Values("Forwarded")); len(elements) > 0 {
+		hops := make([]string, len(elements))
+		for i, elem := range elements {
+			hops[i] = elem.forIP
+		}
+		// the nearest element is written by the trusted peer
+		i := res.clientHop(hops)
+		if i < 0 {
+			i = len(elements) - 1
+		}
+		if value := param(elements[i]); value != "" {
+			return value
+		}
+	}
+
+	values := splitList(r.Header.Values(header))
+	if len(values) == 0 {
+		return ""
+	}
+	i := len(values) - 1
+	// every proxy appends a value, so the values line up with the hops of X-Forwarded-For
+	if hops := splitList(r.Header.Values("X-Forwarded-For")); len(hops) == len(values) {
+		if hop := res.clientHop(hops); hop >= 0 {
+			i = hop
+		}
+	}
+	return values[i]
+}
+
+// Scheme returns the scheme the client used, forwarded by a trusted proxy or http/https of the connection.
+// A forwarded scheme other than http or https is ignored.
+func (res *proxyResolver) Scheme(r *http.Request) string {
+	if res.fromTrustedProxy(r) {
+		proto := res.forwardedValue(r, func(elem forwardedElement) string { return elem.proto }, "X-Forwarded-Proto")
+		if proto = strings.ToLower(proto); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host the client requested, forwarded by a trusted proxy or the Host header.
+func (res *proxyResolver) Host(r *http.Request) string {
+	if res.fromTrustedProxy(r) {
+		if host := res.forwardedValue(r, func(elem forwardedElement) string { return elem.host }, "X-Forwarded-Host"); host != "" {
+			return host
+		}
+	}
+
+	return r.Host
+}
@@ -0,0 +1,363 @@
+package prouter
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newProxyRequest(remoteAddr string, header http.Header) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	return r
+}
+
+func TestProxyResolverClientIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		trusted  []string
+		platform string
+		remote   string
+		header   http.Header
+		want     string
+	}{
+		{
+			name:   "no trusted proxies ignores headers",
+			remote: "203.0.113.1:1234",
+			header: http.Header{"X-Forwarded-For": {"1.1.1.1"}, "X-Real-Ip": {"2.2.2.2"}},
+			want:   "203.0.113.1",
+		},
+		{
+			name:    "untrusted peer ignores headers",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "203.0.113.1:1234",
+			header:  http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+			want:    "203.0.113.1",
+		},
+		{
+			name:    "x-forwarded-for single hop",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"X-Forwarded-For": {"198.51.100.7"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-forwarded-for spoofed leftmost value",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.7, 10.0.0.2"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-forwarded-for over several headers",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"X-Forwarded-For": {"1.1.1.1", "198.51.100.7"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-forwarded-for only trusted hops",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			want:    "10.0.0.3",
+		},
+		{
+			name:    "x-forwarded-for garbage hop",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"X-Forwarded-For": {"198.51.100.7, unknown"}},
+			want:    "10.0.0.1",
+		},
+		{
+			name:    "forwarded header",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"Forwarded": {`for=1.1.1.1, for="[2001:db8::1]:4711";proto=https`}},
+			want:    "2001:db8::1",
+		},
+		{
+			name:    "forwarded takes precedence",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"Forwarded": {"for=198.51.100.7"}, "X-Forwarded-For": {"198.51.100.8"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-real-ip",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"X-Real-Ip": {"198.51.100.7"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:     "platform header",
+			trusted:  []string{"10.0.0.0/8"},
+			platform: PlatformCloudflare,
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"Cf-Connecting-Ip": {"198.51.100.9"}, "X-Forwarded-For": {"198.51.100.7"}},
+			want:     "198.51.100.9",
+		},
+		{
+			name:     "platform header from untrusted peer",
+			trusted:  []string{"10.0.0.0/8"},
+			platform: PlatformCloudflare,
+			remote:   "203.0.113.1:1234",
+			header:   http.Header{"Cf-Connecting-Ip": {"198.51.100.9"}},
+			want:     "203.0.113.1",
+		},
+		{
+			name:    "single trusted ip",
+			trusted: []string{"10.0.0.1"},
+			remote:  "10.0.0.1:1234",
+			header:  http.Header{"X-Forwarded-For": {"198.51.100.7"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:   "ipv6 peer",
+			remote: "[2001:db8:0:0::1]:1234",
+			want:   "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []RouterOption{WithTrustedProxies(tt.trusted...)}
+			if tt.platform != "" {
+				opts = append(opts, WithTrustedPlatform(tt.platform))
+			}
+			r := New(opts...)
+
+			if got := r.ClientIP(newProxyRequest(tt.remote, tt.header)); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTrustedProxiesInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("an invalid network did not panic")
+		}
+	}()
+	New(WithTrustedProxies("10.0.0.0/33"))
+}
+
+func TestProxyResolverSchemeHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remote     string
+		tls        bool
+		header     http.Header
+		wantScheme string
+		wantHost   string
+	}{
+		{
+			name:       "direct request ignores headers",
+			remote:     "203.0.113.1:1234",
+			header:     http.Header{"X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"evil.com"}},
+			wantScheme: "http",
+			wantHost:   "example.com",
+		},
+		{
+			name:       "untrusted peer ignores headers",
+			trusted:    []string{"10.0.0.0/8"},
+			remote:     "203.0.113.1:1234",
+			header:     http.Header{"Forwarded": {"for=1.1.1.1;proto=https;host=evil.com"}},
+			wantScheme: "http",
+			wantHost:   "example.com",
+		},
+		{
+			name:       "x-forwarded single proxy",
+			trusted:    []string{"10.0.0.0/8"},
+			remote:     "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-Proto": {"HTTPS"}, "X-Forwarded-Host": {"api.example.com"}},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:    "x-forwarded spoofed leftmost values",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"1.1.1.1, 198.51.100.7"},
+				"X-Forwarded-Proto": {"http, https"},
+				"X-Forwarded-Host":  {"evil.com, api.example.com"},
+			},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:    "x-forwarded chain of trusted proxies",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"1.1.1.1, 198.51.100.7, 10.0.0.2"},
+				"X-Forwarded-Proto": {"http, https, http"},
+				"X-Forwarded-Host":  {"evil.com, api.example.com, internal"},
+			},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:    "x-forwarded values not lined up with the hops",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header: http.Header{
+				"X-Forwarded-Proto": {"http, https"},
+				"X-Forwarded-Host":  {"evil.com", "api.example.com"},
+			},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:    "forwarded spoofed leftmost element",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header: http.Header{"Forwarded": {
+				`for=1.1.1.1;proto=http;host=evil.com, for=198.51.100.7;proto=https;host="api.example.com"`,
+			}},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:    "forwarded chain of trusted proxies",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header: http.Header{"Forwarded": {
+				"for=198.51.100.7;proto=https;host=api.example.com",
+				"for=10.0.0.2;proto=http;host=internal",
+			}},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:    "forwarded without proto falls back",
+			trusted: []string{"10.0.0.0/8"},
+			remote:  "10.0.0.1:1234",
+			header: http.Header{
+				"Forwarded":         {"for=198.51.100.7"},
+				"X-Forwarded-Proto": {"https"},
+			},
+			wantScheme: "https",
+			wantHost:   "example.com",
+		},
+		{
+			name:       "x-forwarded unknown scheme",
+			trusted:    []string{"10.0.0.0/8"},
+			remote:     "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-Proto": {"javascript"}},
+			wantScheme: "http",
+			wantHost:   "example.com",
+		},
+		{
+			name:       "forwarded scheme with a host",
+			trusted:    []string{"10.0.0.0/8"},
+			remote:     "10.0.0.1:1234",
+			header:     http.Header{"Forwarded": {`for=198.51.100.7;proto="https://evil.com"`}},
+			wantScheme: "http",
+			wantHost:   "example.com",
+		},
+		{
+			name:       "tls connection with an unknown scheme",
+			trusted:    []string{"10.0.0.0/8"},
+			remote:     "10.0.0.1:1234",
+			tls:        true,
+			header:     http.Header{"X-Forwarded-Proto": {"ftp"}},
+			wantScheme: "https",
+			wantHost:   "example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := New(WithTrustedProxies(tt.trusted...)).proxyResolver
+			r := newProxyRequest(tt.remote, tt.header)
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+
+			if got := res.Scheme(r); got != tt.wantScheme {
+				t.Errorf("Scheme() = %q, want %q", got, tt.wantScheme)
+			}
+			if got := res.Host(r); got != tt.wantHost {
+				t.Errorf("Host() = %q, want %q", got, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestRedirectLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		trusted  []string
+		remote   string
+		header   http.Header
+		location string
+		want     string
+	}{
+		{
+			name:     "direct request keeps a relative location",
+			remote:   "203.0.113.1:1234",
+			header:   http.Header{"X-Forwarded-Host": {"evil.com"}},
+			location: "/login",
+			want:     "/login",
+		},
+		{
+			name:     "untrusted peer keeps a relative location",
+			trusted:  []string{"10.0.0.0/8"},
+			remote:   "203.0.113.1:1234",
+			header:   http.Header{"X-Forwarded-Host": {"evil.com"}},
+			location: "/login",
+			want:     "/login",
+		},
+		{
+			name:     "trusted proxy",
+			trusted:  []string{"10.0.0.0/8"},
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"api.example.com"}},
+			location: "/login",
+			want:     "https://api.example.com/login",
+		},
+		{
+			name:     "trusted proxy resolves a path relative location",
+			trusted:  []string{"10.0.0.0/8"},
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"api.example.com"}},
+			location: "next",
+			want:     "https://api.example.com/users/next",
+		},
+		{
+			name:     "absolute location",
+			trusted:  []string{"10.0.0.0/8"},
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-Host": {"api.example.com"}},
+			location: "https://other.example.com/",
+			want:     "https://other.example.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(WithTrustedProxies(tt.trusted...))
+			r.GET("/users/me", func(ctx *Context) (Response, error) {
+				return ctx.Redirect(http.StatusFound, tt.location)
+			})
+
+			req := newProxyRequest(tt.remote, tt.header)
+			req.URL.Path = "/users/me"
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package prouter
+
+// raceEnabled is set when the tests run with the race detector, which allocates on its own.
+const raceEnabled = true
@@ -0,0 +1,227 @@
+package prouter
+
+import (
+	"net/http"
+	"regexp/syntax"
+	"slices"
+	"strings"
+)
+
+// radixRoute is a route in the leaves of the radix tree, index is its position in the order of the routes.
+type radixRoute struct {
+	EngineRoute
+	index int
+}
+
+// radixNode is a node of the radix tree. The static parts of the templates are compressed into the
+// prefixes of the nodes, a variable is a param child matching one segment, or a catch-all when its
+// pattern can match a slash.
+type radixNode struct {
+	prefix   string
+	children []*radixNode
+	param    *radixNode
+	catchAll []*radixRoute
+	routes   []*radixRoute
+}
+
+// RadixEngine narrows the routes down with a radix tree of their path templates, so that the cost
+// of matching does not grow with the number of routes. It supports the {var} and {var:pattern}
+// templates of gorilla/mux, the host, method and other matchers are checked by the route.
+type RadixEngine struct {
+	plain   radixNode
+	encoded radixNode
+	count   int
+	// hasEncoded is set when a route matches against the encoded path
+	hasEncoded bool
+}
+
+func NewRadixEngine() Engine {
+	return &RadixEngine{}
+}
+
+func (e *RadixEngine) Handle(route EngineRoute) {
+	root := &e.plain
+	if route.EncodedPath {
+		root = &e.encoded
+		e.hasEncoded = true
+	}
+
+	// the methods are compared with the method of the request, which mux uppercases too
+	route.Method = strings.ToUpper(route.Method)
+	rr := &radixRoute{EngineRoute: route, index: e.count}
+	e.count++
+	root.insert(route.Path, rr)
+}
+
+func (e *RadixEngine) Lookup(r *http.Request) (http.Handler, map[string]string, bool) {
+	var buf [8]*radixRoute
+	candidates := e.plain.collect(r.URL.Path, buf[:0])
+	if e.hasEncoded {
+		candidates = e.encoded.collect(r.URL.EscapedPath(), candidates)
+		slices.SortFunc(candidates, func(a, b *radixRoute) int { return a.index - b.index })
+	}
+
+	for _, c := range candidates {
+		if c.Method != "" && c.Method != r.Method {
+			continue
+		}
+		if h, vars, ok := c.Match(r); ok {
+			return h, vars, true
+		}
+	}
+	return nil, nil, false
+}
+
+// insert adds the route under the template, a variable consumes the rest of its segment.
+func (n *radixNode) insert(tpl string, route *radixRoute) {
+	for {
+		start := strings.IndexByte(tpl, '{')
+		if start < 0 {
+			n = n.addStatic(tpl)
+			n.routes = append(n.routes, route)
+			return
+		}
+		n = n.addStatic(tpl[:start])
+
+		end, slash := segmentEnd(tpl[start:])
+		if slash {
+			n.catchAll = append(n.catchAll, route)
+			return
+		}
+		if n.param == nil {
+			n.param = &radixNode{}
+		}
+		n = n.param
+		tpl = tpl[start+end:]
+	}
+}
+
+// segmentEnd returns the end of the segment starting at a variable of tpl, and whether a variable
+// of the segment can match a slash.
+func segmentEnd(tpl string) (int, bool) {
+	var slash bool
+	level, varStart := 0, 0
+	for i := 0; i < len(tpl); i++ {
+		switch tpl[i] {
+		case '{':
+			if level == 0 {
+				varStart = i
+			}
+			level++
+		case '}':
+			if level--; level == 0 {
+				_, expr, _ := strings.Cut(tpl[varStart+1:i], ":")
+				slash = slash || patternMatchesSlash(varPattern(expr))
+			}
+		case '/':
+			if level == 0 {
+				return i, slash
+			}
+		}
+	}
+	return len(tpl), slash
+}
+
+// patternMatchesSlash reports whether the regexp can match a slash, an invalid one is assumed to.
+func patternMatchesSlash(pattern string) bool {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return true
+	}
+	return matchesRune(re.Simplify(), '/')
+}
+
+func matchesRune(re *syntax.Regexp, r rune) bool {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpLiteral:
+		return slices.Contains(re.Rune, r)
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i] <= r && r <= re.Rune[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, sub := range re.Sub {
+		if matchesRune(sub, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// addStatic adds the static path s below n and returns the node where it ends.
+func (n *radixNode) addStatic(s string) *radixNode {
+	for s != "" {
+		var child *radixNode
+		for _, c := range n.children {
+			if c.prefix[0] == s[0] {
+				child = c
+				break
+			}
+		}
+		if child == nil {
+			child = &radixNode{prefix: s}
+			n.children = append(n.children, child)
+			return child
+		}
+
+		l := commonPrefix(s, child.prefix)
+		if l < len(child.prefix) {
+			tail := *child
+			tail.prefix = child.prefix[l:]
+			*child = radixNode{prefix: child.prefix[:l], children: []*radixNode{&tail}}
+		}
+		n, s = child, s[l:]
+	}
+	return n
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// collect appends the routes whose template can match the rest of the path p to out,
+// in the order of the routes.
+func (n *radixNode) collect(p string, out []*radixRoute) []*radixRoute {
+	before := len(out)
+	out = n.collectAll(p, out)
+	if len(out)-before > 1 {
+		slices.SortFunc(out[before:], func(a, b *radixRoute) int { return a.index - b.index })
+	}
+	return out
+}
+
+func (n *radixNode) collectAll(p string, out []*radixRoute) []*radixRoute {
+	if p == "" {
+		out = append(out, n.routes...)
+	}
+	out = append(out, n.catchAll...)
+
+	if p != "" {
+		for _, c := range n.children {
+			if c.prefix[0] == p[0] {
+				if strings.HasPrefix(p, c.prefix) {
+					out = c.collectAll(p[len(c.prefix):], out)
+				}
+				break
+			}
+		}
+	}
+
+	if n.param != nil {
+		end := strings.IndexByte(p, '/')
+		if end < 0 {
+			end = len(p)
+		}
+		out = n.param.collectAll(p[end:], out)
+	}
+	return out
+}
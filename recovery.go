@@ -21,6 +21,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/go-puzzles/puzzles/plog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -30,10 +32,30 @@ var (
 	slash     = []byte("/")
 )
 
-type RecoveryMiddleware struct{}
+type RecoveryMiddleware struct {
+	redactor *redactor
+}
+
+type RecoveryOption func(*RecoveryMiddleware)
 
-func NewRecoveryMiddleware() *RecoveryMiddleware {
-	return &RecoveryMiddleware{}
+// WithRecoveryRedactHeaders adds headers whose value is masked in the panic report,
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie are masked by default.
+func WithRecoveryRedactHeaders(headers ...string) RecoveryOption {
+	return func(m *RecoveryMiddleware) {
+		m.redactor.addHeaders(headers...)
+	}
+}
+
+func NewRecoveryMiddleware(opts ...RecoveryOption) *RecoveryMiddleware {
+	m := &RecoveryMiddleware{
+		redactor: newRedactor(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
 }
 
 func stack(skip int) []byte {
@@ -120,7 +142,7 @@ func (m *RecoveryMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 				headers := strings.Split(string(httpRequest), "\r\n")
 				for idx, header := range headers {
 					current := strings.Split(header, ":")
-					if current[0] == "Authorization" {
+					if _, ok := m.redactor.headers[http.CanonicalHeaderKey(current[0])]; ok {
 						headers[idx] = current[0] + ": *"
 					}
 				}
@@ -136,7 +158,16 @@ func (m *RecoveryMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 						http.StatusInternalServerError,
 						fmt.Errorf("panic recovered: %s", recoverErr),
 					).SetComponent(ErrRecovery)
+					if ctx.bodyCapture != nil {
+						// attach the bodies captured by BodyLogMiddleware to the panic report
+						plog.Errorc(ctx, "panic request: %v.", append([]any{ctx.Path}, ctx.bodyCapture.args()...)...)
+					}
 					plog.Errorc(ctx, string(stack))
+
+					// mark the span started by TracingMiddleware as failed
+					trace.SpanFromContext(ctx).RecordError(err, trace.WithAttributes(
+						attribute.String("exception.stacktrace", string(stack)),
+					))
 				}
 			}
 		}()
@@ -0,0 +1,128 @@
+package prouter
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The helpers below write the response themselves and return the nil Response and nil error
+// which tell the router that the response has already been written. A handler returns them
+// directly, e.g. return ctx.JSON(http.StatusCreated, user).
+
+// JSON writes v as the JSON body without the response envelope.
+func (c *Context) JSON(code int, v any) (Response, error) {
+	if err := WriteJSON(c.Writer, code, v); err != nil {
+		return nil, NewErr(http.StatusInternalServerError, err).SetComponent(ErrProuter)
+	}
+	return nil, nil
+}
+
+// String writes a text/plain body formatted with args.
+func (c *Context) String(code int, format string, args ...any) (Response, error) {
+	c.Writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.Writer.WriteHeader(code)
+	if len(args) > 0 {
+		_, _ = fmt.Fprintf(c.Writer, format, args...)
+	} else {
+		_, _ = c.Writer.WriteString(format)
+	}
+	return nil, nil
+}
+
+func (c *Context) Data(code int, contentType string, data []byte) (Response, error) {
+	c.Writer.Header().Set("Content-Type", contentType)
+	c.Writer.WriteHeader(code)
+	_, _ = c.Writer.Write(data)
+	return nil, nil
+}
+
+func (c *Context) NoContent() (Response, error) {
+	c.Writer.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+// File serves the file at path with http.ServeContent, which answers Range and conditional
+// requests. A weak ETag is derived from the size and the modification time of the file.
+func (c *Context) File(path string) (Response, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fileError(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fileError(err)
+	}
+	if info.IsDir() {
+		return nil, ResourceNotFound(http.StatusNotFound, "file not found").SetComponent(ErrProuter)
+	}
+
+	if c.Writer.Header().Get("ETag") == "" {
+		c.Writer.Header().Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+	}
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), f)
+	return nil, nil
+}
+
+func fileError(err error) Error {
+	if os.IsNotExist(err) {
+		return NewErr(http.StatusNotFound, err, "file not found").
+			SetComponent(ErrProuter).
+			SetResponseType(NotFound)
+	}
+	if os.IsPermission(err) {
+		return NewErr(http.StatusForbidden, err, "forbidden").
+			SetComponent(ErrProuter).
+			SetResponseType(Forbidden)
+	}
+	return NewErr(http.StatusInternalServerError, err).SetComponent(ErrProuter)
+}
+
+// Attachment sends r as a download named name. Range requests are answered when r is an io.ReadSeeker.
+func (c *Context) Attachment(name string, r io.Reader) (Response, error) {
+	header := c.Writer.Header()
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+
+	if rs, ok := r.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, name, time.Time{}, rs)
+		return nil, nil
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	c.Writer.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(c.Writer, r)
+	return nil, nil
+}
+
+// Stream calls step until it returns false or the client goes away, the response is flushed after
+// every step. It suits server-sent events and other chunked responses.
+func (c *Context) Stream(step func(w io.Writer) bool) (Response, error) {
+	done := c.Request.Context().Done()
+	if !c.Writer.Written() {
+		c.Writer.WriteHeader(http.StatusOK)
+	}
+
+	for {
+		select {
+		case <-done:
+			return nil, nil
+		default:
+		}
+
+		keepOpen := step(c.Writer)
+		c.Writer.Flush()
+		if !keepOpen {
+			return nil, nil
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package prouter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type renderRequest struct {
+	header   http.Header
+	wantCode int
+	wantBody string
+	// wantHeader are the expected response headers, an empty value expects the header to be absent
+	wantHeader map[string]string
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "digits.txt"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "digits.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+
+	tests := []struct {
+		name     string
+		handler  HandleFunc
+		requests []renderRequest
+	}{
+		{
+			name: "json",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.JSON(http.StatusCreated, map[string]string{"name": "<gopher>"})
+			},
+			requests: []renderRequest{{
+				wantCode:   http.StatusCreated,
+				wantBody:   `{"name":"<gopher>"}` + "\n",
+				wantHeader: map[string]string{"Content-Type": "application/json"},
+			}},
+		},
+		{
+			name: "string",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.String(http.StatusAccepted, "%s is %d", "answer", 42)
+			},
+			requests: []renderRequest{{
+				wantCode:   http.StatusAccepted,
+				wantBody:   "answer is 42",
+				wantHeader: map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+			}},
+		},
+		{
+			name: "string without args",
+			handler: func(ctx *Context) (Response, error) {
+				// a body without args is written as is
+				body := "100%s"
+				return ctx.String(http.StatusOK, body)
+			},
+			requests: []renderRequest{{wantCode: http.StatusOK, wantBody: "100%s"}},
+		},
+		{
+			name: "data",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.Data(http.StatusOK, "image/png", []byte{0x89, 'P', 'N', 'G'})
+			},
+			requests: []renderRequest{{
+				wantCode:   http.StatusOK,
+				wantBody:   "\x89PNG",
+				wantHeader: map[string]string{"Content-Type": "image/png"},
+			}},
+		},
+		{
+			name: "file",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.File(filepath.Join(dir, "digits.txt"))
+			},
+			requests: []renderRequest{
+				{
+					wantCode: http.StatusOK,
+					wantBody: "0123456789",
+					wantHeader: map[string]string{
+						"ETag":          etag,
+						"Content-Type":  "text/plain; charset=utf-8",
+						"Accept-Ranges": "bytes",
+					},
+				},
+				{
+					header:     http.Header{"Range": {"bytes=2-4"}},
+					wantCode:   http.StatusPartialContent,
+					wantBody:   "234",
+					wantHeader: map[string]string{"Content-Range": "bytes 2-4/10"},
+				},
+				{
+					header:   http.Header{"If-None-Match": {etag}},
+					wantCode: http.StatusNotModified,
+				},
+				{
+					header:   http.Header{"If-Modified-Since": {info.ModTime().UTC().Format(http.TimeFormat)}},
+					wantCode: http.StatusNotModified,
+				},
+				{
+					header:   http.Header{"If-None-Match": {`W/"other"`}},
+					wantCode: http.StatusOK,
+					wantBody: "0123456789",
+				},
+			},
+		},
+		{
+			name: "missing file",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.File(filepath.Join(dir, "missing.txt"))
+			},
+			requests: []renderRequest{{wantCode: http.StatusNotFound}},
+		},
+		{
+			name: "directory",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.File(dir)
+			},
+			requests: []renderRequest{{wantCode: http.StatusNotFound}},
+		},
+		{
+			name: "attachment",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.Attachment("report.csv", io.MultiReader(strings.NewReader("a,b\n")))
+			},
+			requests: []renderRequest{{
+				wantCode: http.StatusOK,
+				wantBody: "a,b\n",
+				wantHeader: map[string]string{
+					"Content-Disposition": "attachment; filename=report.csv",
+					"Content-Type":        "text/csv; charset=utf-8",
+				},
+			}},
+		},
+		{
+			name: "attachment of a read seeker",
+			handler: func(ctx *Context) (Response, error) {
+				return ctx.Attachment("digits.bin", strings.NewReader("0123456789"))
+			},
+			requests: []renderRequest{{
+				header:     http.Header{"Range": {"bytes=-3"}},
+				wantCode:   http.StatusPartialContent,
+				wantBody:   "789",
+				wantHeader: map[string]string{"Content-Disposition": "attachment; filename=digits.bin"},
+			}},
+		},
+		{
+			name: "stream",
+			handler: func(ctx *Context) (Response, error) {
+				n := 0
+				return ctx.Stream(func(w io.Writer) bool {
+					n++
+					_, _ = io.WriteString(w, "data: "+strings.Repeat("x", n)+"\n\n")
+					return n < 3
+				})
+			},
+			requests: []renderRequest{{
+				wantCode: http.StatusOK,
+				wantBody: "data: x\n\ndata: xx\n\ndata: xxx\n\n",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.GET("/render", tt.handler)
+
+			for _, req := range tt.requests {
+				hr := httptest.NewRequest(http.MethodGet, "/render", nil)
+				for key, values := range req.header {
+					hr.Header[key] = values
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, hr)
+
+				if w.Code != req.wantCode {
+					t.Errorf("%v: status = %d, want %d: %s", req.header, w.Code, req.wantCode, w.Body)
+					continue
+				}
+				if req.wantBody != "" && w.Body.String() != req.wantBody {
+					t.Errorf("%v: body = %q, want %q", req.header, w.Body, req.wantBody)
+				}
+				for key, want := range req.wantHeader {
+					if got := w.Header().Get(key); got != want {
+						t.Errorf("%v: %s = %q, want %q", req.header, key, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAttachmentFilename(t *testing.T) {
+	for _, name := range []string{
+		"report.csv",
+		"my report.csv",
+		`quote"d.csv`,
+		"résumé.pdf",
+		"a;b=c.txt",
+		`back\slash.txt`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := New()
+			r.GET("/download", func(ctx *Context) (Response, error) {
+				return ctx.Attachment(name, strings.NewReader("data"))
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/download", nil))
+
+			disposition := w.Header().Get("Content-Disposition")
+			mt, params, err := mime.ParseMediaType(disposition)
+			if err != nil || mt != "attachment" || params["filename"] != name {
+				t.Errorf("Content-Disposition = %q parsed as %q %v %v", disposition, mt, params, err)
+			}
+			if len(params) != 1 {
+				t.Errorf("Content-Disposition = %q has extra parameters %v", disposition, params)
+			}
+		})
+	}
+}
+
+func TestStreamStopsWhenClientGoesAway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	steps := 0
+
+	r := New()
+	r.GET("/events", func(c *Context) (Response, error) {
+		return c.Stream(func(w io.Writer) bool {
+			steps++
+			if steps == 2 {
+				cancel()
+			}
+			_, _ = io.WriteString(w, "tick\n")
+			return true
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx))
+
+	if steps != 2 || w.Body.String() != "tick\ntick\n" {
+		t.Errorf("steps = %d, body = %q, want the stream to stop once the request is canceled", steps, w.Body)
+	}
+	if !w.Flushed {
+		t.Error("the steps were not flushed")
+	}
+}
@@ -0,0 +1,224 @@
+package prouter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+const defaultMultipartMemory = 32 << 20
+
+// queryValues parses the query once per request.
+func (c *Context) queryValues() url.Values {
+	if c.query == nil {
+		c.query = c.Request.URL.Query()
+	}
+	return c.query
+}
+
+func (c *Context) Query(key string) string {
+	return c.queryValues().Get(key)
+}
+
+// QueryDefault returns def when the key is not in the query, an empty value is returned as it is.
+func (c *Context) QueryDefault(key, def string) string {
+	if values, ok := c.queryValues()[key]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return def
+}
+
+func (c *Context) QueryArray(key string) []string {
+	return c.queryValues()[key]
+}
+
+// QueryMap returns the values of the keys in the form key[name], e.g. filter[status]=open.
+func (c *Context) QueryMap(key string) map[string]string {
+	m := make(map[string]string)
+	for k, values := range c.queryValues() {
+		name, ok := strings.CutPrefix(k, key+"[")
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if name, ok = strings.CutSuffix(name, "]"); ok && name != "" {
+			m[name] = values[0]
+		}
+	}
+	return m
+}
+
+func (c *Context) Header(key string) string {
+	return c.Request.Header.Get(key)
+}
+
+// Cookie returns the unescaped value of the named cookie, or http.ErrNoCookie.
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
+
+// SetCookie adds a Set-Cookie header to the response, the value is escaped. The cookie is not modified.
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	cp := *cookie
+	if cp.Path == "" {
+		cp.Path = "/"
+	}
+	cp.Value = url.QueryEscape(cp.Value)
+	http.SetCookie(c.Writer, &cp)
+}
+
+// limitBody caps the body at the size of WithMaxBodySize before it is read, the body is not capped
+// unless the option is used.
+func (c *Context) limitBody() {
+	if c.bodyLimited || c.router == nil || c.router.maxBodySize <= 0 {
+		return
+	}
+	c.bodyLimited = true
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, c.router.maxBodySize)
+	}
+}
+
+// bodyError turns an error reading the body into a response, 413 when the body exceeds the cap.
+func bodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return NewErr(http.StatusRequestEntityTooLarge, err, "request body too large").
+			SetComponent(ErrProuter).
+			SetResponseType(PayloadTooLarge)
+	}
+	return NewErr(http.StatusBadRequest, err, "read request body failed").
+		SetComponent(ErrProuter).
+		SetResponseType(BadRequest)
+}
+
+// parseForm parses the url encoded or multipart body once, the request keeps the parsed values.
+func (c *Context) parseForm() error {
+	if c.Request.MultipartForm != nil || c.Request.PostForm != nil {
+		return nil
+	}
+	c.limitBody()
+	// ParseMultipartForm drops the error of the url encoded body when the body is not multipart
+	if err := c.Request.ParseForm(); err != nil {
+		return bodyError(err)
+	}
+	err := c.Request.ParseMultipartForm(defaultMultipartMemory)
+	if errors.Is(err, http.ErrNotMultipart) {
+		return nil
+	}
+	if err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+// FormValue returns the value of the key in the body or the query, the body takes precedence.
+func (c *Context) FormValue(key string) string {
+	_ = c.parseForm()
+	return c.Request.FormValue(key)
+}
+
+func (c *Context) FormFile(key string) (*multipart.FileHeader, error) {
+	if err := c.parseForm(); err != nil {
+		return nil, err
+	}
+	_, fh, err := c.Request.FormFile(key)
+	return fh, err
+}
+
+// body reads the request body once, so that it can be bound by middlewares and the handler.
+// The error is a 400 or 413 response.
+func (c *Context) body() ([]byte, error) {
+	if c.bodyCache != nil {
+		return c.bodyCache, nil
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		c.bodyCache = []byte{}
+		return c.bodyCache, nil
+	}
+
+	c.limitBody()
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	c.bodyCache = data
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// Bind binds the body by its content type, the path variables, the headers and the query into v.
+// The body is cached, so Bind can be called more than once per request. The error is a 400 response,
+// or a 413 response when the body exceeds the cap of WithMaxBodySize.
+func (c *Context) Bind(v any) error {
+	r := c.Request
+
+	var (
+		err     error
+		errMsg  string
+		bodyErr error
+	)
+	func() {
+		binder := binding.Default(r.Method, contentType(r))
+		if bb, ok := binder.(binding.BindingBody); ok {
+			var data []byte
+			if data, bodyErr = c.body(); bodyErr != nil {
+				return
+			}
+			err = bb.BindBody(data, v)
+		} else {
+			if bodyErr = c.parseForm(); bodyErr != nil {
+				return
+			}
+			err = binder.Bind(r, v)
+		}
+		if err != nil {
+			errMsg = "parse request data failed"
+			return
+		}
+
+		if len(c.vars) > 0 {
+			m := make(map[string][]string)
+			for key, val := range c.vars {
+				m[key] = []string{val}
+			}
+			if err = binding.Uri.BindUri(m, v); err != nil {
+				errMsg = "parse request data failed"
+				return
+			}
+		}
+
+		if len(r.Header) > 0 {
+			if err = binding.Header.Bind(r, v); err != nil {
+				errMsg = "parse request header data failed"
+				return
+			}
+		}
+
+		if query := c.queryValues(); len(query) > 0 {
+			if err = binding.MapFormWithTag(v, query, "form"); err != nil {
+				errMsg = "parse request query data failed"
+				return
+			}
+		}
+	}()
+
+	if bodyErr != nil {
+		return bodyErr
+	}
+	if errMsg != "" {
+		return NewErr(http.StatusBadRequest, err, errMsg).
+			SetComponent(ErrProuter).
+			SetResponseType(BadRequest)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBindMaxBodySize(t *testing.T) {
+	bindHandler := func(ctx *Context) (Response, error) {
+		var v struct {
+			Name string `json:"name" form:"name"`
+		}
+		if err := ctx.Bind(&v); err != nil {
+			return nil, err
+		}
+		return ctx.String(http.StatusOK, v.Name)
+	}
+
+	tests := []struct {
+		name        string
+		opts        []RouterOption
+		contentType string
+		body        string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "json under the cap",
+			opts:        []RouterOption{WithMaxBodySize(64)},
+			contentType: "application/json",
+			body:        `{"name":"gopher"}`,
+			wantCode:    200,
+			wantBody:    "gopher",
+		},
+		{
+			name:        "json over the cap",
+			opts:        []RouterOption{WithMaxBodySize(16)},
+			contentType: "application/json",
+			body:        `{"name":"` + strings.Repeat("a", 32) + `"}`,
+			wantCode:    413,
+		},
+		{
+			name:        "form over the cap",
+			opts:        []RouterOption{WithMaxBodySize(16)},
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=" + strings.Repeat("a", 32),
+			wantCode:    413,
+		},
+		{
+			name:        "cap disabled",
+			opts:        []RouterOption{WithMaxBodySize(-1)},
+			contentType: "application/json",
+			body:        `{"name":"` + strings.Repeat("a", 32) + `"}`,
+			wantCode:    200,
+			wantBody:    strings.Repeat("a", 32),
+		},
+		{
+			name:        "no cap by default",
+			contentType: "application/json",
+			body:        `{"name":"` + strings.Repeat("a", 11<<20) + `"}`,
+			wantCode:    200,
+			wantBody:    strings.Repeat("a", 11<<20),
+		},
+		{
+			name:        "invalid json",
+			contentType: "application/json",
+			body:        `{"name":`,
+			wantCode:    400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.opts...)
+			r.POST("/bind", bindHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSetCookie(t *testing.T) {
+	cookie := &http.Cookie{Name: "session", Value: "a b;c"}
+
+	r := New()
+	r.GET("/login", func(ctx *Context) (Response, error) {
+		ctx.SetCookie(cookie)
+		ctx.SetCookie(cookie)
+		return ctx.NoContent()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	if cookie.Value != "a b;c" || cookie.Path != "" {
+		t.Errorf("SetCookie modified the cookie: %+v", cookie)
+	}
+	for _, header := range w.Header().Values("Set-Cookie") {
+		if header != "session=a+b%3Bc; Path=/" {
+			t.Errorf("Set-Cookie = %q", header)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", w.Header().Get("Set-Cookie"))
+	ctx := &Context{Request: req}
+	if got, err := ctx.Cookie("session"); err != nil || got != "a b;c" {
+		t.Errorf("Cookie() = %q, %v", got, err)
+	}
+}
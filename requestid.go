@@ -0,0 +1,125 @@
+package prouter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/google/uuid"
+)
+
+const maxRequestIDLength = 128
+
+// RequestIDGenerator creates a new request id.
+type RequestIDGenerator func() string
+
+// UUIDv7Generator generates time ordered uuid v7 request ids, it is the default generator.
+func UUIDv7Generator() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator generates ULID request ids.
+func ULIDGenerator() string {
+	var data [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(data[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(data[2:6], uint32(ms))
+	_, _ = rand.Read(data[6:])
+
+	// 128 bits encoded into 26 characters, 5 bits per character from the most significant bit
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(data[0:8])
+	lo := binary.BigEndian.Uint64(data[8:16])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+type RequestIDMiddleware struct {
+	header    string
+	generator RequestIDGenerator
+	trust     bool
+}
+
+type RequestIDOption func(*RequestIDMiddleware)
+
+// WithRequestIDHeader changes the header used to read and write the request id, default is X-Request-ID.
+func WithRequestIDHeader(header string) RequestIDOption {
+	return func(m *RequestIDMiddleware) {
+		m.header = header
+	}
+}
+
+func WithRequestIDGenerator(generator RequestIDGenerator) RequestIDOption {
+	return func(m *RequestIDMiddleware) {
+		m.generator = generator
+	}
+}
+
+// WithRequestIDTrustIncoming decides whether an id sent by the client is reused, it is true by default.
+func WithRequestIDTrustIncoming(trust bool) RequestIDOption {
+	return func(m *RequestIDMiddleware) {
+		m.trust = trust
+	}
+}
+
+func NewRequestIDMiddleware(opts ...RequestIDOption) *RequestIDMiddleware {
+	m := &RequestIDMiddleware{
+		header:    requestIDHeader,
+		generator: UUIDv7Generator,
+		trust:     true,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *RequestIDMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		id := ctx.Request.Header.Get(m.header)
+		if !m.trust || !validRequestID(id) {
+			id = m.generator()
+		}
+
+		ctx.requestID = id
+		ctx.Writer.Header().Set(m.header, id)
+		ctx.Context = plog.With(ctx.Context, "requestId", id)
+		ctx.WithValue(ContextRequestIDKey, id)
+
+		return handler.Handle(ctx)
+	})
+}
+
+// RequestIDFromContext returns the request id set by RequestIDMiddleware,
+// it can be used with the context passed into downstream services.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextRequestIDKey).(string)
+	return id
+}
@@ -0,0 +1,99 @@
+package prouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestULIDGenerator(t *testing.T) {
+	before := time.Now().UnixMilli()
+	id := ULIDGenerator()
+	after := time.Now().UnixMilli()
+
+	if len(id) != 26 {
+		t.Fatalf("len(%s) = %d, want 26", id, len(id))
+	}
+	// the first character only carries 3 bits of the 48 bits timestamp
+	if id[0] > '7' {
+		t.Errorf("%s overflows 128 bits", id)
+	}
+
+	var ms int64
+	for i := 0; i < 10; i++ {
+		n := strings.IndexByte(crockfordBase32, id[i])
+		if n < 0 {
+			t.Fatalf("%s contains %q which is not crockford base32", id, id[i])
+		}
+		ms = ms<<5 | int64(n)
+	}
+	if ms < before || ms > after {
+		t.Errorf("timestamp of %s = %d, want within [%d, %d]", id, ms, before, after)
+	}
+
+	if a, b := ULIDGenerator(), ULIDGenerator(); a == b {
+		t.Errorf("two ids are equal: %s", a)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []RequestIDOption
+		header   string
+		incoming string
+		wantSame bool
+	}{
+		{name: "generated", header: requestIDHeader},
+		{name: "incoming trusted", header: requestIDHeader, incoming: "abc-123", wantSame: true},
+		{name: "incoming invalid", header: requestIDHeader, incoming: "a b"},
+		{name: "incoming too long", header: requestIDHeader, incoming: strings.Repeat("a", maxRequestIDLength+1)},
+		{
+			name:     "incoming untrusted",
+			opts:     []RequestIDOption{WithRequestIDTrustIncoming(false)},
+			header:   requestIDHeader,
+			incoming: "abc-123",
+		},
+		{
+			name:     "custom header",
+			opts:     []RequestIDOption{WithRequestIDHeader("X-Trace")},
+			header:   "X-Trace",
+			incoming: "abc-123",
+			wantSame: true,
+		},
+		{
+			name:   "custom generator",
+			opts:   []RequestIDOption{WithRequestIDGenerator(func() string { return "fixed" })},
+			header: requestIDHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID, valueID string
+			r := New()
+			r.UseMiddleware(NewRequestIDMiddleware(tt.opts...))
+			r.GET("/", func(ctx *Context) (Response, error) {
+				ctxID, valueID = ctx.RequestID(), RequestIDFromContext(ctx)
+				return ctx.NoContent()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(tt.header, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(tt.header)
+			if got == "" || got != ctxID || got != valueID {
+				t.Fatalf("response id %q, ctx.RequestID %q, RequestIDFromContext %q", got, ctxID, valueID)
+			}
+			if (got == tt.incoming) != tt.wantSame {
+				t.Errorf("id = %q, incoming %q, want reused %v", got, tt.incoming, tt.wantSame)
+			}
+		})
+	}
+}
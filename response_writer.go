@@ -1,24 +1,162 @@
 package prouter
 
-import "net/http"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
 
-type ResponseWriter struct {
+	"github.com/go-puzzles/puzzles/plog"
+)
+
+const noWritten = -1
+
+var (
+	_ http.Flusher  = (*ResponseWriter)(nil)
+	_ http.Hijacker = (*ResponseWriter)(nil)
+	_ http.Pusher   = (*ResponseWriter)(nil)
+	_ io.ReaderFrom = (*ResponseWriter)(nil)
+)
+
+// passthroughWriter passes the optional interfaces of http.ResponseWriter through to the
+// wrapped writer, it is embedded by the writers which wrap the writer of the server.
+type passthroughWriter struct {
 	http.ResponseWriter
+}
+
+func (w *passthroughWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *passthroughWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	return hj.Hijack()
+}
+
+func (w *passthroughWriter) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
+}
+
+// Unwrap returns the original writer, it is used by http.ResponseController.
+func (w *passthroughWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type ResponseWriter struct {
+	passthroughWriter
 	statusCode int
+	// size is the number of body bytes written, noWritten means headers are not sent yet
+	size int64
 }
 
+// WriteHeader sends the status code once, later calls are ignored.
 func (w *ResponseWriter) WriteHeader(code int) {
-	if code > 0 && w.statusCode != code {
-		w.statusCode = code
+	if code <= 0 {
+		return
 	}
 
+	// informational responses can be sent before the final status
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	if w.Written() {
+		if prouterMode == DebugMode && code != w.statusCode {
+			plog.Warnf("[WARNING] Headers were already written. Wanted to override status code %d with %d", w.statusCode, code)
+		}
+		return
+	}
+
+	w.statusCode = code
+	w.size = 0
 	w.ResponseWriter.WriteHeader(code)
 }
 
+func (w *ResponseWriter) writeHeaderNow() {
+	if !w.Written() {
+		w.WriteHeader(w.statusCode)
+	}
+}
+
+func (w *ResponseWriter) Write(data []byte) (n int, err error) {
+	w.writeHeaderNow()
+	n, err = w.ResponseWriter.Write(data)
+	w.size += int64(n)
+	return
+}
+
+func (w *ResponseWriter) WriteString(s string) (n int, err error) {
+	w.writeHeaderNow()
+	n, err = io.WriteString(w.ResponseWriter, s)
+	w.size += int64(n)
+	return
+}
+
+// ReadFrom uses the io.ReaderFrom of the underlying writer if it has one, so sendfile still works.
+func (w *ResponseWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	w.writeHeaderNow()
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, r)
+	}
+	w.size += n
+	return
+}
+
 func (w *ResponseWriter) StatusCode() int {
 	return w.statusCode
 }
 
+// Size returns the number of body bytes written.
+func (w *ResponseWriter) Size() int64 {
+	if w.size == noWritten {
+		return 0
+	}
+	return w.size
+}
+
+// Written reports whether the headers have been sent.
+func (w *ResponseWriter) Written() bool {
+	return w.size != noWritten
+}
+
+func (w *ResponseWriter) Flush() {
+	if _, ok := w.ResponseWriter.(http.Flusher); !ok {
+		return
+	}
+
+	w.writeHeaderNow()
+	w.passthroughWriter.Flush()
+}
+
+// Hijack lets the caller take over the connection, it is required by websocket upgrades.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.passthroughWriter.Hijack()
+	if err == nil {
+		w.statusCode = http.StatusSwitchingProtocols
+		if w.size == noWritten {
+			w.size = 0
+		}
+	}
+	return conn, rw, err
+}
+
+type writerOnly struct {
+	io.Writer
+}
+
 func WrapResponseWriter(w http.ResponseWriter) *ResponseWriter {
-	return &ResponseWriter{w, http.StatusOK}
+	return &ResponseWriter{passthroughWriter{w}, http.StatusOK, noWritten}
 }
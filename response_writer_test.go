@@ -0,0 +1,183 @@
+package prouter
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingWriter records the status codes sent and supports the optional interfaces of a server writer.
+type recordingWriter struct {
+	*httptest.ResponseRecorder
+	codes    []int
+	deadline time.Time
+	conn     net.Conn
+}
+
+func newRecordingWriter() *recordingWriter {
+	return &recordingWriter{ResponseRecorder: httptest.NewRecorder()}
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	w.codes = append(w.codes, code)
+	if code >= 200 {
+		w.ResponseRecorder.WriteHeader(code)
+	}
+}
+
+func (w *recordingWriter) SetWriteDeadline(t time.Time) error {
+	w.deadline = t
+	return nil
+}
+
+func (w *recordingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	w.conn = client
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+// readerFromWriter is a recordingWriter with io.ReaderFrom, like the writer of net/http.
+type readerFromWriter struct {
+	*recordingWriter
+	readFrom int
+}
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom++
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestResponseWriterStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		write     func(w *ResponseWriter)
+		wantCodes []int
+		wantSize  int64
+		wantBody  string
+	}{
+		{
+			name:      "implicit 200",
+			write:     func(w *ResponseWriter) { _, _ = w.Write([]byte("hello")) },
+			wantCodes: []int{200},
+			wantSize:  5,
+			wantBody:  "hello",
+		},
+		{
+			name: "second WriteHeader ignored",
+			write: func(w *ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.WriteString("ok")
+			},
+			wantCodes: []int{201},
+			wantSize:  2,
+			wantBody:  "ok",
+		},
+		{
+			name: "informational responses pass through",
+			write: func(w *ResponseWriter) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusAccepted)
+			},
+			wantCodes: []int{103, 103, 202},
+		},
+		{
+			name:      "invalid code ignored",
+			write:     func(w *ResponseWriter) { w.WriteHeader(0); w.WriteHeader(http.StatusNoContent) },
+			wantCodes: []int{204},
+		},
+		{
+			name:  "nothing written",
+			write: func(w *ResponseWriter) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newRecordingWriter()
+			w := WrapResponseWriter(rec)
+			tt.write(w)
+
+			if !slices.Equal(rec.codes, tt.wantCodes) {
+				t.Errorf("codes = %v, want %v", rec.codes, tt.wantCodes)
+			}
+			if w.Written() != (len(tt.wantCodes) > 0) {
+				t.Errorf("Written() = %v", w.Written())
+			}
+			if w.Size() != tt.wantSize || rec.Body.String() != tt.wantBody {
+				t.Errorf("Size() = %d, body = %q, want %d %q", w.Size(), rec.Body, tt.wantSize, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	rf := &readerFromWriter{recordingWriter: newRecordingWriter()}
+	w := WrapResponseWriter(rf)
+	if n, err := w.ReadFrom(strings.NewReader("sendfile")); n != 8 || err != nil {
+		t.Fatalf("ReadFrom() = %d, %v", n, err)
+	}
+	if rf.readFrom != 1 || w.Size() != 8 || rf.Body.String() != "sendfile" {
+		t.Errorf("ReadFrom of the writer used %d times, Size() = %d, body = %q", rf.readFrom, w.Size(), rf.Body)
+	}
+
+	// a writer without io.ReaderFrom is copied to, the ReadFrom of the wrapper must not recurse
+	rec := httptest.NewRecorder()
+	w = WrapResponseWriter(rec)
+	if n, err := w.ReadFrom(strings.NewReader("copied")); n != 6 || err != nil {
+		t.Fatalf("ReadFrom() = %d, %v", n, err)
+	}
+	if w.Size() != 6 || rec.Body.String() != "copied" || rec.Code != http.StatusOK {
+		t.Errorf("Size() = %d, body = %q, code = %d", w.Size(), rec.Body, rec.Code)
+	}
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	rec := newRecordingWriter()
+	w := WrapResponseWriter(rec)
+
+	conn, _, err := w.Hijack()
+	if err != nil {
+		t.Fatalf("Hijack() = %v", err)
+	}
+	defer conn.Close()
+	defer rec.conn.Close()
+
+	if w.StatusCode() != http.StatusSwitchingProtocols || !w.Written() || w.Size() != 0 {
+		t.Errorf("StatusCode() = %d, Written() = %v, Size() = %d", w.StatusCode(), w.Written(), w.Size())
+	}
+	if len(rec.codes) != 0 {
+		t.Errorf("the hijacked writer sent %v", rec.codes)
+	}
+
+	if _, _, err := WrapResponseWriter(httptest.NewRecorder()).Hijack(); err == nil {
+		t.Error("Hijack() of a writer without http.Hijacker succeeded")
+	}
+}
+
+func TestResponseWriterUnwrap(t *testing.T) {
+	rec := newRecordingWriter()
+	w := WrapResponseWriter(rec)
+	if w.Unwrap() != http.ResponseWriter(rec) {
+		t.Fatal("Unwrap() does not return the wrapped writer")
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+		t.Fatalf("SetWriteDeadline() = %v", err)
+	}
+	if !rec.deadline.Equal(deadline) {
+		t.Errorf("deadline = %v, want %v", rec.deadline, deadline)
+	}
+
+	if err := w.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Errorf("Push() = %v, want http.ErrNotSupported", err)
+	}
+}
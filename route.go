@@ -1,8 +1,10 @@
 package prouter
 
 import (
+	"regexp"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/mux"
 )
@@ -21,22 +23,81 @@ type OptRoute interface {
 // iRoute the final iRoute group which will use to register into mux.Router
 type iRoute struct {
 	Route
-	router      *mux.Router
-	middleware  []Middleware
-	routeOption RouteOption
+	router       *mux.Router
+	middleware   []Middleware
+	routeOptions []RouteOption
+	// match is recorded when the route options are applied
+	match routeMatch
+	// template is the full path template of the route including the group prefix
+	template string
+	// params are the variables of the path, converters the converters of the converted ones
+	params     []ParamInfo
+	converters map[string]Converter
 }
 
 type RouteOption func(*mux.Route) *mux.Route
 
+// WithRouteName names a route so that its url can be built by Prouter.URL and Context.URLFor.
+func WithRouteName(name string) RouteOption {
+	return func(r *mux.Route) *mux.Route {
+		return r.Name(name)
+	}
+}
+
 func (r *iRoute) handleSpecifyMiddleware(handler handlerFunc) handlerFunc {
-	next := handler
-	for _, m := range slices.Backward(r.middleware) {
+	return compileChain(r.middleware, handler)
+}
+
+// compileChain builds the chain of a route once at registration. Consecutive HandleFunc middlewares
+// run from a flat slice by index instead of being nested into closures, other middlewares
+// wrap the rest of the chain.
+func compileChain(middlewares []Middleware, handler handlerFunc) handlerFunc {
+	var (
+		next  = handler
+		steps []HandleFunc
+	)
+	flush := func() {
+		if len(steps) == 0 {
+			return
+		}
+		slices.Reverse(steps)
+		next = &flatChain{steps: steps, next: next}
+		steps = nil
+	}
+
+	for _, m := range slices.Backward(middlewares) {
+		if f, ok := m.(HandleFunc); ok {
+			steps = append(steps, f)
+			continue
+		}
+		flush()
 		next = m.WrapHandler(next)
 	}
+	flush()
 
 	return next
 }
 
+// flatChain runs the steps in order and then next, it stops at the first step returning an error
+// like HandleFunc.WrapHandler does.
+type flatChain struct {
+	steps []HandleFunc
+	next  handlerFunc
+}
+
+func (c *flatChain) Name() string {
+	return c.next.Name()
+}
+
+func (c *flatChain) Handle(ctx *Context) (Response, error) {
+	for i := 0; i < len(c.steps); i++ {
+		if resp, err := c.steps[i](ctx); err != nil {
+			return resp, err
+		}
+	}
+	return c.next.Handle(ctx)
+}
+
 type defaultRoute struct {
 	method  string
 	path    string
@@ -78,3 +139,63 @@ func newHandlerFuncRoute(method, path string, handler handlerFunc, opts ...Route
 func NewRoute(method, path string, handler HandleFunc, opts ...RouteOption) Route {
 	return newHandlerFuncRoute(method, path, handler, opts...)
 }
+
+// RouteInfo describes a registered route and the path handling it was registered with.
+type RouteInfo struct {
+	Method          string
+	Path            string
+	Name            string
+	Handler         string
+	TrailingSlash   TrailingSlashPolicy
+	CleanPath       bool
+	CaseInsensitive bool
+	EncodedPath     bool
+	Params          []ParamInfo
+}
+
+// routeEntry is a route in the registry of the router.
+type routeEntry struct {
+	info   RouteInfo
+	route  *mux.Route
+	policy pathPolicy
+	// group is the group the route was registered on
+	group *RouterGroup
+	// shape is the template without the variable names, match what the route matches besides
+	// its path and method, see findConflict
+	shape string
+	match routeMatch
+
+	foldOnce  sync.Once
+	fold      *regexp.Regexp
+	foldNames []string
+}
+
+// matchFold matches p case insensitively against the template and returns the variables as key value pairs.
+func (e *routeEntry) matchFold(p string) ([]string, bool) {
+	e.foldOnce.Do(func() {
+		e.fold, e.foldNames = foldRegexp(e.info.Path)
+	})
+	if e.fold == nil {
+		return nil, false
+	}
+
+	m := e.fold.FindStringSubmatch(p)
+	if m == nil {
+		return nil, false
+	}
+
+	pairs := make([]string, 0, 2*len(e.foldNames))
+	for i, name := range e.foldNames {
+		pairs = append(pairs, name, m[i+1])
+	}
+	return pairs, true
+}
+
+// RouteTable returns the registered routes in the order of registration.
+func (v *Prouter) RouteTable() []RouteInfo {
+	infos := make([]RouteInfo, 0, len(v.routes))
+	for _, entry := range v.routes {
+		infos = append(infos, entry.info)
+	}
+	return infos
+}
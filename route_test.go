@@ -0,0 +1,116 @@
+package prouter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// traceMiddleware is a middleware which is not a HandleFunc, it ends the flat runs of compileChain.
+type traceMiddleware struct {
+	name  string
+	trace *[]string
+}
+
+func (m traceMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (Response, error) {
+		*m.trace = append(*m.trace, m.name+">")
+		resp, err := handler.Handle(ctx)
+		*m.trace = append(*m.trace, "<"+m.name)
+		return resp, err
+	})
+}
+
+func TestCompileChain(t *testing.T) {
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name    string
+		chain   string
+		stopAt  string
+		want    string
+		wantErr error
+	}{
+		{name: "handler only", chain: "", want: "handler"},
+		{name: "flat", chain: "a b c", want: "a b c handler"},
+		{name: "wrapping middleware", chain: "a W b c", want: "a W> b c handler <W"},
+		{name: "wrapping middlewares only", chain: "W X", want: "W> X> handler <X <W"},
+		{name: "stop", chain: "a b c", stopAt: "b", want: "a b", wantErr: errStop},
+		{name: "stop inside a wrapping middleware", chain: "a W b", stopAt: "b", want: "a W> b <W", wantErr: errStop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				trace       []string
+				middlewares []Middleware
+			)
+			for _, name := range strings.Fields(tt.chain) {
+				if strings.ToUpper(name) == name {
+					middlewares = append(middlewares, traceMiddleware{name: name, trace: &trace})
+					continue
+				}
+				middlewares = append(middlewares, HandleFunc(func(ctx *Context) (Response, error) {
+					trace = append(trace, name)
+					if name == tt.stopAt {
+						return nil, errStop
+					}
+					return nil, nil
+				}))
+			}
+			handler := HandleFunc(func(ctx *Context) (Response, error) {
+				trace = append(trace, "handler")
+				return nil, nil
+			})
+
+			_, err := compileChain(middlewares, handler).Handle(&Context{})
+			if got := strings.Join(trace, " "); got != tt.want {
+				t.Errorf("trace = %q, want %q", got, tt.want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// nestedChain wraps the handler into the middlewares one by one, which is what compileChain replaces.
+func nestedChain(middlewares []Middleware, handler handlerFunc) handlerFunc {
+	next := handler
+	for _, m := range slices.Backward(middlewares) {
+		next = m.WrapHandler(next)
+	}
+	return next
+}
+
+func BenchmarkChain(b *testing.B) {
+	noop := HandleFunc(func(ctx *Context) (Response, error) { return nil, nil })
+
+	for _, depth := range []int{5, 10, 20} {
+		middlewares := make([]Middleware, depth)
+		for i := range middlewares {
+			middlewares[i] = noop
+		}
+
+		for _, bc := range []struct {
+			name  string
+			chain func([]Middleware, handlerFunc) handlerFunc
+		}{
+			{name: "nested", chain: nestedChain},
+			{name: "flat", chain: compileChain},
+		} {
+			b.Run(fmt.Sprintf("%s/depth=%d", bc.name, depth), func(b *testing.B) {
+				chain := bc.chain(middlewares, noop)
+				ctx := &Context{}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, _ = chain.Handle(ctx)
+				}
+			})
+		}
+	}
+}
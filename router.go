@@ -1,10 +1,15 @@
 package prouter
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-puzzles/puzzles/plog"
@@ -40,6 +45,37 @@ type Prouter struct {
 	host   string
 	scheme string
 	// middlewares []Middleware
+
+	proxyResolver *proxyResolver
+	// notFound handles the requests whose path matches no route
+	notFound HandleFunc
+	// methodNotAllowed handles the requests whose path is registered but the method is not
+	methodNotAllowed HandleFunc
+	// methods contains all the registered methods
+	methods map[string]struct{}
+	// routes is the registry of the registered routes, routeIndex indexes it by the mux route
+	routes     []*routeEntry
+	routeIndex map[*mux.Route]*routeEntry
+	// routeShapes indexes the routes by the shape of their template to find duplicates
+	routeShapes map[string][]*routeEntry
+	// groupRoutes are the path prefix routes of the groups, see currentEngine
+	groupRoutes map[*mux.Route]*RouterGroup
+	// conflicts are the routes shadowed by earlier routes, they panic at registration in strict mode
+	conflicts    []RouteConflict
+	strictRoutes bool
+
+	// contextPool reuses the contexts of finished requests
+	contextPool sync.Pool
+	// newEngine creates the engine matching the requests before the mux router, see WithEngine
+	newEngine   func() Engine
+	engineState engineState
+
+	// maxBodySize caps the bodies read by the context, see WithMaxBodySize
+	maxBodySize int64
+
+	server        atomic.Pointer[http.Server]
+	shutdownDelay time.Duration
+	shuttingDown  atomic.Bool
 }
 
 type RouterOption func(v *Prouter)
@@ -56,6 +92,39 @@ func WithScheme(scheme string) RouterOption {
 	}
 }
 
+// WithShutdownDelay keeps serving for d after Shutdown is called while readiness
+// is failing, so that load balancers have time to remove the instance.
+func WithShutdownDelay(d time.Duration) RouterOption {
+	return func(v *Prouter) {
+		v.shutdownDelay = d
+	}
+}
+
+// WithMaxBodySize caps the request bodies read by Bind and the form accessors at n bytes,
+// a larger body is answered with 413 Request Entity Too Large. The bodies are not capped by
+// default, n <= 0 disables the cap.
+func WithMaxBodySize(n int64) RouterOption {
+	return func(v *Prouter) {
+		v.maxBodySize = n
+	}
+}
+
+// WithNotFound sets the handler for the paths which match no route. Unlike WithNotFoundHandler
+// it runs through the middlewares of the root group.
+func WithNotFound(handler HandleFunc) RouterOption {
+	return func(v *Prouter) {
+		v.notFound = handler
+	}
+}
+
+// WithMethodNotAllowed sets the handler for the paths which are registered with other methods.
+// It runs through the middlewares of the group the path belongs to.
+func WithMethodNotAllowed(handler HandleFunc) RouterOption {
+	return func(v *Prouter) {
+		v.methodNotAllowed = handler
+	}
+}
+
 func WithNotFoundHandler(handler http.Handler) RouterOption {
 	return func(v *Prouter) {
 		v.router.NotFoundHandler = handler
@@ -73,22 +142,74 @@ func (v *Prouter) parseOptions(opts ...RouterOption) {
 		opt(v)
 	}
 	if v.host != "" {
-		v.router = v.router.Host(v.host).Subrouter()
+		v.router = v.subrouter(v.router.Host(v.host))
 	}
 
 	if v.scheme != "" {
-		v.router = v.router.Schemes(v.host).Subrouter()
+		v.router = v.subrouter(v.router.Schemes(v.scheme))
 	}
 }
 
+// subrouter replaces the root router by a subrouter of route, which serves the requests
+// and keeps the handlers of the root.
+func (v *Prouter) subrouter(route *mux.Route) *mux.Router {
+	router := route.Subrouter()
+	router.SkipClean(true)
+	router.NotFoundHandler = v.router.NotFoundHandler
+	router.MethodNotAllowedHandler = v.router.MethodNotAllowedHandler
+	return router
+}
+
+func (v *Prouter) addRoute(route *mux.Route, r iRoute, group *RouterGroup) {
+	policy := group.policy
+	entry := &routeEntry{
+		info: RouteInfo{
+			Method:          r.Method(),
+			Path:            r.template,
+			Name:            route.GetName(),
+			Handler:         r.Handler().Name(),
+			TrailingSlash:   policy.trailingSlash,
+			CleanPath:       !policy.skipClean,
+			CaseInsensitive: policy.caseInsensitive,
+			EncodedPath:     policy.encodedPath,
+			Params:          r.params,
+		},
+		route:  route,
+		policy: policy,
+		group:  group,
+		shape:  templateShape(r.template),
+		match:  r.match,
+	}
+	v.routeIndex[route] = entry
+	v.checkConflict(entry)
+	v.routes = append(v.routes, entry)
+	v.routeShapes[entry.shape] = append(v.routeShapes[entry.shape], entry)
+	v.engineState.dirty.Store(true)
+}
+
 func New(opts ...RouterOption) *Prouter {
 	m := mux.NewRouter()
 
 	v := &Prouter{
-		RouterGroup: newGroupWithRouter(m),
+		RouterGroup:      newGroupWithRouter(m),
+		proxyResolver:    &proxyResolver{},
+		notFound:         notFound,
+		methodNotAllowed: methodNotAllowed,
+		methods:          make(map[string]struct{}),
+		routeIndex:       make(map[*mux.Route]*routeEntry),
+		routeShapes:      make(map[string][]*routeEntry),
+		groupRoutes:      make(map[*mux.Route]*RouterGroup),
 	}
+	v.contextPool.New = func() any { return new(Context) }
 	v.RouterGroup.root = true
 	v.RouterGroup.prouter = v
+	// paths are cleaned by the router with the policy of the route they lead to
+	m.SkipClean(true)
+	m.NotFoundHandler = v.RouterGroup.notFoundHandler()
+	// groups let a method mismatch fall through to the routes after them like gorilla/mux does,
+	// only the root replies 405
+	v.RouterGroup.methodNotAllowed = v.RouterGroup.methodNotAllowedHandler()
+	m.MethodNotAllowedHandler = http.HandlerFunc(v.serveMethodNotAllowed)
 	v.parseOptions(opts...)
 
 	return v
@@ -100,28 +221,73 @@ func NewProuter(opts ...RouterOption) *Prouter {
 		NewLogMiddleware(),
 		NewRecoveryMiddleware(),
 	)
-
-	notFoundHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_ = WriteJSON(w, http.StatusNotFound, ErrorResponse(http.StatusNotFound, "page not found"))
-	})
-	v.router.NotFoundHandler = notFoundHandler
-	v.router.MethodNotAllowedHandler = notFoundHandler
 	return v
 }
 func (v *Prouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if v.redirectCleanPath(w, r) || v.serveEngine(w, r) {
+		return
+	}
 	v.router.ServeHTTP(w, r)
 }
 
-func (v *Prouter) ServeHandler() *mux.Router {
+// URL builds the url of a named route, pairs are the variables of the path template as key value pairs.
+func (v *Prouter) URL(name string, pairs ...string) (*url.URL, error) {
+	route := v.router.Get(name)
+	if route == nil {
+		return nil, fmt.Errorf("route %q not found", name)
+	}
+	return route.URL(pairs...)
+}
+
+// ServeHandler returns the handler serving the requests with the engine of the router.
+// It used to return the *mux.Router, which serves the requests without the engine; use
+// MuxRouter to reach the gorilla/mux router.
+func (v *Prouter) ServeHandler() http.Handler {
+	return v
+}
+
+// MuxRouter returns the gorilla/mux router the routes are registered on. With an Engine it still
+// builds the urls and handles the requests the engine has no route for.
+//
+// Routes added directly on it are not seen by the engine, the conflict check and RouteTable:
+// with an Engine they are only reached by the requests the engine has no route for.
+// Register them through the router or its groups instead.
+func (v *Prouter) MuxRouter() *mux.Router {
 	return v.router
 }
 
 func (v *Prouter) Run(addr string) error {
-	srv := http.Server{
+	server := &http.Server{
 		Addr:    addr,
 		Handler: v,
 	}
-	return srv.ListenAndServe()
+	v.server.Store(server)
+	return server.ListenAndServe()
+}
+
+// Shutdown marks the router as shutting down, which fails the readiness check, and
+// gracefully shuts down the server started by Run after the shutdown delay.
+func (v *Prouter) Shutdown(ctx context.Context) error {
+	v.shuttingDown.Store(true)
+
+	if v.shutdownDelay > 0 {
+		select {
+		case <-time.After(v.shutdownDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	server := v.server.Load()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// ShuttingDown reports whether Shutdown has been called.
+func (v *Prouter) ShuttingDown() bool {
+	return v.shuttingDown.Load()
 }
 
 func (v *Prouter) handlerName(handler handlerFunc) string {
@@ -133,32 +299,25 @@ func (v *Prouter) handlerName(handler handlerFunc) string {
 
 func (v *Prouter) makeHttpHandler(wr iRoute) http.HandlerFunc {
 	handlerName := wr.Handler().Name()
-	handlerFunc := wr.handleSpecifyMiddleware(wr.Handler())
+	handler := wr.Handler()
+	if len(wr.converters) > 0 {
+		handler = convertVars(wr.converters, handler)
+	}
+	handlerFunc := wr.handleSpecifyMiddleware(handler)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
-		raw := r.URL.RawQuery
-		if raw != "" {
-			path = path + "?" + raw
-		}
-
-		ctx := &Context{
-			Context:   plog.With(r.Context(), "handler", handlerName),
-			Writer:    WrapResponseWriter(w),
-			Path:      path,
-			Method:    r.Method,
-			ClientIp:  clientIP(r),
-			startTime: time.Now(),
-		}
-		r = r.Clone(ctx)
+		ctx := v.contextPool.Get().(*Context)
+		ctx.reset(v, w, r)
+		ctx.handlerName = handlerName
+		ctx.route = wr.template
+		ctx.Context = plog.With(r.Context(), "handler", handlerName)
+		// the request keeps its own context, the pooled context must not outlive the handler
 		ctx.Request = r
-
-		vars := mux.Vars(ctx.Request)
-		if vars == nil {
-			vars = make(map[string]string)
-		}
-		ctx.vars = vars
-		ctx.router = v
+		ctx.vars = mux.Vars(r)
+		defer func() {
+			ctx.finish()
+			v.contextPool.Put(ctx)
+		}()
 
 		code, resp := v.packResponseTmpl(handlerFunc.Handle(ctx))
 		if code == -1 {
@@ -166,7 +325,7 @@ func (v *Prouter) makeHttpHandler(wr iRoute) http.HandlerFunc {
 		}
 
 		status := mapCodeToStatus(code)
-		_ = WriteJSON(w, status, resp)
+		_ = WriteJSON(ctx.Writer, status, resp)
 	}
 }
 
@@ -0,0 +1,151 @@
+package prouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// discardWriter is a response writer which does not allocate.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// setReleaseMode keeps the debug output of the router out of the benchmarks.
+func setReleaseMode(tb testing.TB) {
+	mode := prouterMode
+	SetMode(ReleaseMode)
+	tb.Cleanup(func() { SetMode(int64(mode)) })
+}
+
+func newBenchRouter(tb testing.TB) *Prouter {
+	setReleaseMode(tb)
+
+	r := New()
+	r.GET("/users/{id}", func(ctx *Context) (Response, error) {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return nil, nil
+	})
+	return r
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	r := newBenchRouter(b)
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	w := &discardWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+// measuredServeAllocs is what BenchmarkServeHTTP reports for a request to a route with a variable,
+// most of it is taken by gorilla/mux to store the route and the variables in the request and by
+// the logger fields. The margin lets a dependency add an allocation without failing the test,
+// a regression of the pooling costs more than that.
+const (
+	measuredServeAllocs = 16
+	serveAllocsMargin   = 3
+	maxServeAllocs      = measuredServeAllocs + serveAllocsMargin
+)
+
+func TestServeHTTPAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	r := newBenchRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	w := &discardWriter{header: make(http.Header)}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		r.ServeHTTP(w, req)
+	})
+	if allocs > maxServeAllocs {
+		t.Errorf("ServeHTTP allocates %v times per request, want at most %d", allocs, maxServeAllocs)
+	}
+}
+
+func TestPooledContextReset(t *testing.T) {
+	r := New()
+	r.POST("/items/{id:int}", func(ctx *Context) (Response, error) {
+		if _, ok := ctx.params["id"]; !ok {
+			t.Errorf("%s: the variable was not converted", ctx.Path)
+		}
+		if ctx.bodyCache != nil || ctx.query != nil || ctx.session != nil || ctx.requestID != "" {
+			t.Errorf("%s: the context keeps the state of the previous request", ctx.Path)
+		}
+		if _, ok := ctx.Request.Context().(*Context); ok {
+			t.Errorf("%s: the request holds the pooled context", ctx.Path)
+		}
+		_, _ = ctx.body()
+		_ = ctx.Query("q")
+		ctx.requestID = "id"
+		return ctx.String(http.StatusOK, ctx.Path)
+	})
+
+	for _, target := range []string{"/items/1?q=a", "/items/2", "/items/3?q=b"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code != http.StatusOK || w.Body.String() != target {
+			t.Errorf("%s: status = %d, Path = %q", target, w.Code, w.Body)
+		}
+	}
+}
+
+func TestContextCopy(t *testing.T) {
+	copies := make(chan *Context, 1)
+	r := New()
+	r.GET("/users/{id}", func(ctx *Context) (Response, error) {
+		copies <- ctx.Copy()
+		return ctx.String(http.StatusOK, ctx.Var("id"))
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1?full=1", nil))
+	cp := <-copies
+	// the pooled context is reused by the next request
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	<-copies
+
+	if cp.Var("id") != "1" || cp.Path != "/users/1?full=1" || cp.Query("full") != "1" {
+		t.Errorf("copy = vars %v, path %q", cp.vars, cp.Path)
+	}
+	if cp.Request.URL.Path != "/users/1" {
+		t.Errorf("copy request = %s", cp.Request.URL)
+	}
+}
+
+func TestHealthCheckOutlivesHandler(t *testing.T) {
+	r := New()
+	release := make(chan struct{})
+	r.EnableHealth(WithHealthTimeout(10*time.Millisecond), WithHealthCheck("slow", func(ctx context.Context) error {
+		for {
+			select {
+			case <-release:
+				return nil
+			default:
+				// the values are looked up through the parents of the context
+				_ = ctx.Value(ContextRequestKey)
+			}
+		}
+	}))
+	r.GET("/ping", textHandler("pong"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+	// the timed out check is still running while the pooled context serves other requests
+	for i := 0; i < 10; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	}
+	close(release)
+}
@@ -177,3 +177,8 @@ func (s *RedisStore) generateRandomKey() (string, error) {
 	// }
 	// return strings.TrimRight(base32.StdEncoding.EncodeToString(k), "="), nil
 }
+
+// Ping checks the connection of the redis client, it can be used as a health check.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
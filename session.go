@@ -19,10 +19,11 @@ import (
 )
 
 const (
-	sessionGetterKey        = "prouter:Session:getter"
 	defaultSessionSecretKey = "prouter-Session-secret-key"
 )
 
+var sessionGetterKey = NewKey[sessionGetter]("prouter:Session:getter")
+
 var (
 	SessionNotInitialized = fmt.Errorf("Session not initialized")
 	SessionKeyNotExists   = fmt.Errorf("Session key not exist")
@@ -132,7 +133,7 @@ func (m *SessionMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 			w:       ctx.Writer,
 		}
 		ctx.session = sess
-		ctx.WithValue(sessionGetterKey, m.sessionGetter)
+		sessionGetterKey.Set(ctx, m.sessionGetter)
 		defer func() {
 			if newErr := ctx.session.Save(); newErr != nil {
 				err = errors.Join(err, newErr)
@@ -146,7 +147,7 @@ func (m *SessionMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
 }
 
 func SessionGet(ctx context.Context, r *http.Request, w http.ResponseWriter) (*Session, error) {
-	sessGetter, ok := ctx.Value(sessionGetterKey).(func(r *http.Request, w http.ResponseWriter) (*Session, error))
+	sessGetter, ok := sessionGetterKey.Get(ctx)
 	if !ok {
 		return nil, SessionNotInitialized
 	}
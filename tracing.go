@@ -0,0 +1,187 @@
+package prouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-puzzles/puzzles/plog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/go-puzzles/prouter"
+
+// SpanNameFormatter returns the name of the server span of a request.
+type SpanNameFormatter func(ctx *Context) string
+
+// TracingMiddleware starts an OpenTelemetry server span for every request. The span
+// is exported by the exporters of the configured TracerProvider, e.g. the in-memory
+// exporter of go.opentelemetry.io/otel/sdk/trace/tracetest can be used in tests.
+type TracingMiddleware struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	spanName   SpanNameFormatter
+}
+
+type TracingOption func(*tracingConfig)
+
+type tracingConfig struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	spanName   SpanNameFormatter
+}
+
+// WithTracerProvider sets the provider used to create spans, the global provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) TracingOption {
+	return func(c *tracingConfig) {
+		c.provider = provider
+	}
+}
+
+// WithPropagator sets the propagator, W3C traceparent and tracestate are used by default.
+func WithPropagator(propagator propagation.TextMapPropagator) TracingOption {
+	return func(c *tracingConfig) {
+		c.propagator = propagator
+	}
+}
+
+func WithSpanNameFormatter(fn SpanNameFormatter) TracingOption {
+	return func(c *tracingConfig) {
+		c.spanName = fn
+	}
+}
+
+func defaultSpanName(ctx *Context) string {
+	route := ctx.RouteTemplate()
+	if route == "" {
+		return ctx.Method
+	}
+	return ctx.Method + " " + route
+}
+
+func NewTracingMiddleware(opts ...TracingOption) *TracingMiddleware {
+	conf := &tracingConfig{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+		spanName:   defaultSpanName,
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return &TracingMiddleware{
+		tracer:     conf.provider.Tracer(tracerName),
+		propagator: conf.propagator,
+		spanName:   conf.spanName,
+	}
+}
+
+func (m *TracingMiddleware) startAttributes(ctx *Context) []attribute.KeyValue {
+	r := ctx.Request
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", ctx.Method),
+		attribute.String("url.path", r.URL.Path),
+		attribute.String("url.scheme", scheme),
+		attribute.String("server.address", r.Host),
+		attribute.String("client.address", ctx.ClientIp),
+		attribute.String("network.protocol.version", fmt.Sprintf("%d.%d", r.ProtoMajor, r.ProtoMinor)),
+	}
+	if route := ctx.RouteTemplate(); route != "" {
+		attrs = append(attrs, attribute.String("http.route", route))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, attribute.String("user_agent.original", ua))
+	}
+	if r.ContentLength > 0 {
+		attrs = append(attrs, attribute.Int64("http.request.body.size", r.ContentLength))
+	}
+
+	return attrs
+}
+
+func (m *TracingMiddleware) end(ctx *Context, span trace.Span, err error) {
+	statusCode := ctx.Writer.StatusCode()
+	if err != nil && statusCode == http.StatusOK && !ctx.Writer.Written() {
+		statusCode = http.StatusInternalServerError
+	}
+
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", statusCode),
+		attribute.Int64("http.response.body.size", ctx.Writer.Size()),
+	)
+
+	if err != nil {
+		rErr := new(prouterError)
+		if errors.As(err, &rErr) {
+			span.SetAttributes(
+				attribute.String("prouter.error.component", string(rErr.Component())),
+				attribute.Int("prouter.error.code", rErr.Code()),
+			)
+			if rErr.ResponseErrType() != "" {
+				span.SetAttributes(attribute.String("prouter.error.type", string(rErr.ResponseErrType())))
+			}
+		}
+		span.SetAttributes(attribute.String("error.type", fmt.Sprintf("%T", err)))
+	}
+
+	// only server errors mark a server span as failed, see the http semantic conventions
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		msg := http.StatusText(statusCode)
+		if err != nil {
+			msg = err.Error()
+		}
+		span.SetStatus(codes.Error, msg)
+	case err != nil && statusCode < http.StatusBadRequest:
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func (m *TracingMiddleware) WrapHandler(handler handlerFunc) handlerFunc {
+	return HandleFunc(func(ctx *Context) (resp Response, err error) {
+		carrier := propagation.HeaderCarrier(ctx.Request.Header)
+		parent := m.propagator.Extract(ctx.Context, carrier)
+
+		spanCtx, span := m.tracer.Start(
+			parent,
+			m.spanName(ctx),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(m.startAttributes(ctx)...),
+		)
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			spanCtx = plog.With(spanCtx, "traceId", sc.TraceID().String())
+		}
+		ctx.Context = spanCtx
+		m.propagator.Inject(spanCtx, propagation.HeaderCarrier(ctx.Writer.Header()))
+
+		defer func() {
+			// the panic is not recovered by a RecoveryMiddleware inside this one
+			if recoverErr := recover(); recoverErr != nil {
+				span.RecordError(fmt.Errorf("panic: %v", recoverErr), trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", recoverErr))
+				panic(recoverErr)
+			}
+		}()
+
+		ctx.onFinish(func() {
+			m.end(ctx, span, err)
+		})
+
+		resp, err = handler.Handle(ctx)
+		return
+	})
+}
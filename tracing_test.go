@@ -0,0 +1,226 @@
+package prouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newTracedRouter(t *testing.T, middlewares ...Middleware) (*Prouter, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	r := New()
+	r.UseMiddleware(append([]Middleware{NewTracingMiddleware(WithTracerProvider(provider))}, middlewares...)...)
+	return r, exporter
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func onlySpan(t *testing.T, exporter *tracetest.InMemoryExporter) tracetest.SpanStub {
+	t.Helper()
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func TestTracingPropagation(t *testing.T) {
+	r, exporter := newTracedRouter(t)
+
+	var handlerSpan trace.SpanContext
+	r.GET("/users/{id}", func(ctx *Context) (Response, error) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return ctx.NoContent()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", testTraceParent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	span := onlySpan(t, exporter)
+	if got := span.Parent.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("parent trace id = %s", got)
+	}
+	if got := span.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span id = %s", got)
+	}
+	if !span.Parent.IsRemote() {
+		t.Error("parent is not remote")
+	}
+	if handlerSpan.SpanID() != span.SpanContext.SpanID() {
+		t.Error("the handler does not see the server span in its context")
+	}
+
+	want := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"
+	if got := w.Header().Get("traceparent"); got != want {
+		t.Errorf("response traceparent = %q, want %q", got, want)
+	}
+}
+
+func TestTracingSpanNameAndRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantName  string
+		wantRoute string
+		wantCode  int64
+	}{
+		{name: "route template", path: "/users/42", wantName: "GET /users/{id}", wantRoute: "/users/{id}", wantCode: 204},
+		{name: "converted variable", path: "/orders/7", wantName: "GET /orders/{id:int}", wantRoute: "/orders/{id:int}", wantCode: 204},
+		{name: "not found", path: "/missing", wantName: "GET", wantCode: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, exporter := newTracedRouter(t)
+			noContent := func(ctx *Context) (Response, error) { return ctx.NoContent() }
+			r.GET("/users/{id}", noContent)
+			r.GET("/orders/{id:int}", noContent)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			span := onlySpan(t, exporter)
+			if span.Name != tt.wantName {
+				t.Errorf("span name = %q, want %q", span.Name, tt.wantName)
+			}
+			if span.SpanKind != trace.SpanKindServer {
+				t.Errorf("span kind = %v", span.SpanKind)
+			}
+			route, ok := spanAttr(span, "http.route")
+			if tt.wantRoute == "" && ok || tt.wantRoute != "" && route.AsString() != tt.wantRoute {
+				t.Errorf("http.route = %q, want %q", route.AsString(), tt.wantRoute)
+			}
+			if code, _ := spanAttr(span, "http.response.status_code"); code.AsInt64() != tt.wantCode {
+				t.Errorf("status code = %d, want %d", code.AsInt64(), tt.wantCode)
+			}
+			if path, _ := spanAttr(span, "url.path"); path.AsString() != tt.path {
+				t.Errorf("url.path = %q, want %q", path.AsString(), tt.path)
+			}
+		})
+	}
+}
+
+func TestTracingErrorStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    codes.Code
+		wantCode      int64
+		wantComponent string
+		wantType      string
+	}{
+		{
+			name:          "client error",
+			err:           MsgError(http.StatusBadRequest, "bad input").SetComponent(ErrService).SetResponseType(BadRequest),
+			wantStatus:    codes.Unset,
+			wantCode:      http.StatusBadRequest,
+			wantComponent: string(ErrService),
+			wantType:      string(BadRequest),
+		},
+		{
+			name:          "server error",
+			err:           NewErr(http.StatusServiceUnavailable, errors.New("db down")).SetComponent(ErrRepo),
+			wantStatus:    codes.Error,
+			wantCode:      http.StatusServiceUnavailable,
+			wantComponent: string(ErrRepo),
+		},
+		{
+			name:       "plain error",
+			err:        errors.New("boom"),
+			wantStatus: codes.Error,
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, exporter := newTracedRouter(t)
+			r.GET("/", func(*Context) (Response, error) { return nil, tt.err })
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			span := onlySpan(t, exporter)
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("status = %v %q, want %v", span.Status.Code, span.Status.Description, tt.wantStatus)
+			}
+			if code, _ := spanAttr(span, "http.response.status_code"); code.AsInt64() != tt.wantCode {
+				t.Errorf("status code = %d, want %d", code.AsInt64(), tt.wantCode)
+			}
+			if _, ok := spanAttr(span, "error.type"); !ok {
+				t.Error("error.type is not set")
+			}
+			component, _ := spanAttr(span, "prouter.error.component")
+			if component.AsString() != tt.wantComponent {
+				t.Errorf("prouter.error.component = %q, want %q", component.AsString(), tt.wantComponent)
+			}
+			errType, _ := spanAttr(span, "prouter.error.type")
+			if errType.AsString() != tt.wantType {
+				t.Errorf("prouter.error.type = %q, want %q", errType.AsString(), tt.wantType)
+			}
+		})
+	}
+}
+
+func TestTracingRecoveredPanic(t *testing.T) {
+	r, exporter := newTracedRouter(t, NewRecoveryMiddleware())
+	r.GET("/", func(*Context) (Response, error) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+
+	span := onlySpan(t, exporter)
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want error", span.Status.Code)
+	}
+	if component, _ := spanAttr(span, "prouter.error.component"); component.AsString() != string(ErrRecovery) {
+		t.Errorf("prouter.error.component = %q, want %q", component.AsString(), ErrRecovery)
+	}
+	if len(span.Events) == 0 || span.Events[0].Name != "exception" {
+		t.Fatalf("events = %v, want the recorded panic", span.Events)
+	}
+}
+
+// A panic which is not recovered inside the tracing middleware still ends the span as failed.
+func TestTracingUnrecoveredPanic(t *testing.T) {
+	r, exporter := newTracedRouter(t)
+	r.GET("/", func(*Context) (Response, error) { panic("boom") })
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the panic was swallowed")
+			}
+		}()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	span := onlySpan(t, exporter)
+	if span.Status.Code != codes.Error || span.Status.Description != "panic: boom" {
+		t.Errorf("status = %v %q", span.Status.Code, span.Status.Description)
+	}
+}
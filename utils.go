@@ -6,6 +6,7 @@ import (
 	"mime"
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	
 	"github.com/pkg/errors"
@@ -78,7 +79,13 @@ func remoteIP(r *http.Request) string {
 }
 
 func clientIP(r *http.Request) string {
-	remoteIP := net.ParseIP(remoteIP(r))
+	ip := remoteIP(r)
+	// a parsed ipv4 address is already in the canonical form, which saves the allocation of String
+	if addr, err := netip.ParseAddr(ip); err == nil && addr.Is4() {
+		return ip
+	}
+
+	remoteIP := net.ParseIP(ip)
 	if remoteIP == nil {
 		return ""
 	}
@@ -0,0 +1,296 @@
+package prouter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-puzzles/puzzles/plog"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultWSWriteWait  = 10 * time.Second
+	defaultWSPongWait   = 60 * time.Second
+	defaultWSPingPeriod = defaultWSPongWait * 9 / 10
+)
+
+// WSHandleFunc handles an upgraded websocket connection. The connection is
+// closed after the handler returns, the returned error decides the close code.
+type WSHandleFunc func(ctx *Context, conn *WSConn) error
+
+type wsConfig struct {
+	upgrader     websocket.Upgrader
+	writeWait    time.Duration
+	pongWait     time.Duration
+	pingPeriod   time.Duration
+	readLimit    int64
+	responseHead http.Header
+}
+
+type WSOption func(*wsConfig)
+
+func WithWSUpgrader(upgrader websocket.Upgrader) WSOption {
+	return func(c *wsConfig) {
+		c.upgrader = upgrader
+	}
+}
+
+func WithWSCheckOrigin(fn func(r *http.Request) bool) WSOption {
+	return func(c *wsConfig) {
+		c.upgrader.CheckOrigin = fn
+	}
+}
+
+func WithWSSubprotocols(protocols ...string) WSOption {
+	return func(c *wsConfig) {
+		c.upgrader.Subprotocols = protocols
+	}
+}
+
+func WithWSResponseHeader(header http.Header) WSOption {
+	return func(c *wsConfig) {
+		c.responseHead = header
+	}
+}
+
+// WithWSKeepAlive sets how long to wait for a pong and how often a ping is sent.
+// pingPeriod must be less than pongWait, a zero pingPeriod disables pings.
+func WithWSKeepAlive(pongWait, pingPeriod time.Duration) WSOption {
+	return func(c *wsConfig) {
+		c.pongWait = pongWait
+		c.pingPeriod = pingPeriod
+	}
+}
+
+func WithWSWriteWait(d time.Duration) WSOption {
+	return func(c *wsConfig) {
+		c.writeWait = d
+	}
+}
+
+func WithWSReadLimit(limit int64) WSOption {
+	return func(c *wsConfig) {
+		c.readLimit = limit
+	}
+}
+
+// WSCloseError is returned by a WSHandleFunc to close the connection with a specified code.
+type WSCloseError struct {
+	Code int
+	Text string
+}
+
+func (e *WSCloseError) Error() string {
+	return "websocket: close " + strconv.Itoa(e.Code) + " " + e.Text
+}
+
+// WSClose returns an error closing the connection with code and text. A code which can not be sent
+// in a close frame, e.g. the reserved 1005, 1006 and 1015, is replaced by 1011.
+func WSClose(code int, text string) error {
+	return &WSCloseError{Code: code, Text: text}
+}
+
+// validCloseCode reports whether code may be sent in a close frame, see RFC 6455 section 7.4.
+func validCloseCode(code int) bool {
+	switch code {
+	case websocket.CloseNoStatusReceived, websocket.CloseAbnormalClosure, websocket.CloseTLSHandshake:
+		return false
+	}
+	// 1004 is reserved, 3000-4999 are the codes of libraries and applications
+	return code >= websocket.CloseNormalClosure && code <= 1014 && code != 1004 ||
+		code >= 3000 && code <= 4999
+}
+
+type WSConn struct {
+	*websocket.Conn
+	ctx  *Context
+	conf *wsConfig
+
+	writeMu sync.Mutex
+}
+
+// WriteMessage writes a message with the write deadline applied. It is safe
+// to call from multiple goroutines.
+func (c *WSConn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.Conn.SetWriteDeadline(c.writeDeadline()); err != nil {
+		return err
+	}
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+// WriteJSON writes v as a json text message. It is safe to call from multiple goroutines.
+func (c *WSConn) WriteJSON(v any) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.Conn.SetWriteDeadline(c.writeDeadline()); err != nil {
+		return err
+	}
+	return c.Conn.WriteJSON(v)
+}
+
+func (c *WSConn) Context() *Context {
+	return c.ctx
+}
+
+func (c *WSConn) writeDeadline() time.Time {
+	if c.conf.writeWait <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.conf.writeWait)
+}
+
+func (c *WSConn) keepAlive(done <-chan struct{}) {
+	if c.conf.pingPeriod <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.conf.pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := c.Conn.WriteControl(websocket.PingMessage, nil, c.writeDeadline()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *WSConn) close(err error) {
+	code, text := websocket.CloseNormalClosure, ""
+
+	var (
+		closeErr *WSCloseError
+		peerErr  *websocket.CloseError
+		rErr     *prouterError
+	)
+	switch {
+	case err == nil:
+	case errors.As(err, &peerErr):
+		// the peer already started the closing handshake, echo its code back
+		code = peerErr.Code
+		if !validCloseCode(code) {
+			code = websocket.CloseNormalClosure
+		}
+	case errors.As(err, &closeErr):
+		code, text = closeErr.Code, closeErr.Text
+		if !validCloseCode(code) {
+			code = websocket.CloseInternalServerErr
+		}
+	case errors.As(err, &rErr):
+		code, text = websocket.CloseInternalServerErr, rErr.Message()
+		if rErr.Code() >= http.StatusBadRequest && rErr.Code() < http.StatusInternalServerError {
+			code = websocket.ClosePolicyViolation
+		}
+	default:
+		code, text = websocket.CloseInternalServerErr, err.Error()
+	}
+
+	// close frame payload is limited to 125 bytes including the 2 bytes code
+	if len(text) > 123 {
+		text = text[:123]
+	}
+
+	msg := websocket.FormatCloseMessage(code, text)
+	_ = c.Conn.WriteControl(websocket.CloseMessage, msg, c.writeDeadline())
+	_ = c.Conn.Close()
+}
+
+// WSReadJSON reads the next text message from conn and decodes it into a T.
+func WSReadJSON[T any](conn *WSConn) (*T, error) {
+	v := new(T)
+	if err := conn.ReadJSON(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// WSWriteJSON writes v into conn as json text message.
+func WSWriteJSON[T any](conn *WSConn, v T) error {
+	return conn.WriteJSON(v)
+}
+
+func newWSConfig(opts ...WSOption) *wsConfig {
+	conf := &wsConfig{
+		writeWait:  defaultWSWriteWait,
+		pongWait:   defaultWSPongWait,
+		pingPeriod: defaultWSPingPeriod,
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return conf
+}
+
+func (rg *RouterGroup) wsHandler(handler WSHandleFunc, conf *wsConfig) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		if !websocket.IsWebSocketUpgrade(ctx.Request) {
+			return nil, MsgError(http.StatusBadRequest, "websocket upgrade required").
+				SetComponent(ErrProuter).
+				SetResponseType(BadRequest)
+		}
+
+		// Upgrade replies to the client itself when it fails
+		c, err := conf.upgrader.Upgrade(ctx.Writer, ctx.Request, conf.responseHead)
+		if err != nil {
+			plog.Warnc(ctx, "websocket upgrade failed: %v", err)
+			return nil, nil
+		}
+
+		conn := &WSConn{Conn: c, ctx: ctx, conf: conf}
+		if conf.readLimit > 0 {
+			conn.SetReadLimit(conf.readLimit)
+		}
+		if conf.pongWait > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(conf.pongWait))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(conf.pongWait))
+			})
+		}
+
+		done := make(chan struct{})
+		go conn.keepAlive(done)
+
+		err = handler(ctx, conn)
+		close(done)
+		conn.close(err)
+
+		if err != nil && !isExpectedWSClose(err) {
+			plog.Errorc(ctx, "websocket handler error: %v", err)
+		}
+
+		return nil, nil
+	}
+}
+
+func isExpectedWSClose(err error) bool {
+	var closeErr *WSCloseError
+	if errors.As(err, &closeErr) {
+		return closeErr.Code == websocket.CloseNormalClosure || closeErr.Code == websocket.CloseGoingAway
+	}
+	return websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived)
+}
+
+// WS registers a websocket endpoint. The upgrade happens after the middlewares of the group
+// have been run, so sessions and authorization are available in the handler.
+func (rg *RouterGroup) WS(path string, handler WSHandleFunc, opts ...WSOption) {
+	conf := newWSConfig(opts...)
+	h := &wrapHandler{
+		name:    funcName(handler),
+		handler: rg.wsHandler(handler, conf),
+	}
+
+	rg.handleRoute(http.MethodGet, path, h)
+}
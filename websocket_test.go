@@ -0,0 +1,85 @@
+package prouter
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWSCloseCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  WSHandleFunc
+		wantCode int
+		wantText string
+	}{
+		{
+			name:     "nil error",
+			handler:  func(*Context, *WSConn) error { return nil },
+			wantCode: websocket.CloseNormalClosure,
+		},
+		{
+			name:     "application code",
+			handler:  func(*Context, *WSConn) error { return WSClose(4000, "bye") },
+			wantCode: 4000,
+			wantText: "bye",
+		},
+		{
+			name:     "reserved code",
+			handler:  func(*Context, *WSConn) error { return WSClose(websocket.CloseNoStatusReceived, "gone") },
+			wantCode: websocket.CloseInternalServerErr,
+			wantText: "gone",
+		},
+		{
+			name:     "reserved code built by hand",
+			handler:  func(*Context, *WSConn) error { return &WSCloseError{Code: websocket.CloseAbnormalClosure} },
+			wantCode: websocket.CloseInternalServerErr,
+		},
+		{
+			name:     "plain error",
+			handler:  func(*Context, *WSConn) error { return errors.New("boom") },
+			wantCode: websocket.CloseInternalServerErr,
+			wantText: "boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.WS("/ws", tt.handler)
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+
+			_, _, err = conn.ReadMessage()
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				t.Fatalf("read error = %v, want a close error", err)
+			}
+			if closeErr.Code != tt.wantCode || closeErr.Text != tt.wantText {
+				t.Errorf("close = %d %q, want %d %q", closeErr.Code, closeErr.Text, tt.wantCode, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestValidCloseCode(t *testing.T) {
+	for _, code := range []int{0, 999, 1004, 1005, 1006, 1015, 2999, 5000} {
+		if validCloseCode(code) {
+			t.Errorf("validCloseCode(%d) = true", code)
+		}
+	}
+	for _, code := range []int{1000, 1001, 1008, 1011, 1014, 3000, 4999} {
+		if !validCloseCode(code) {
+			t.Errorf("validCloseCode(%d) = false", code)
+		}
+	}
+}